@@ -0,0 +1,397 @@
+// Package client is a Go client of the qplay web service. It covers the API
+// described by the OpenAPI document the service publishes at
+// /api/openapi.json: executing and plotting circuits, background jobs,
+// backends and stored programs.
+//
+//	c := client.New("http://localhost:8080", client.WithAPIKey(key))
+//	res, err := c.Execute(ctx, &client.CircuitRequest{Circuit: bell, Shots: 1000})
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Client talks to one qplay service. It is safe for concurrent use.
+	Client struct {
+		baseURL string
+		apiKey  string
+		http    *http.Client
+	}
+
+	// Option configures a Client.
+	Option func(*Client)
+
+	// APIError is returned for responses with a non-2xx status.
+	APIError struct {
+		StatusCode int
+		Message    string
+		// Gate is the index of the offending gate of an invalid circuit, or -1.
+		Gate int
+	}
+)
+
+// WithAPIKey sends key as a bearer token with every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// New creates a client of the service at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{baseURL: strings.TrimRight(baseURL, "/"), http: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (e *APIError) Error() string {
+	if e.Gate >= 0 {
+		return fmt.Sprintf("qplay: %d: %s (gate %d)", e.StatusCode, e.Message, e.Gate)
+	}
+	return fmt.Sprintf("qplay: %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var ae *APIError
+	return errors.As(err, &ae) && ae.StatusCode == http.StatusNotFound
+}
+
+// Health checks that the service is up.
+func (c *Client) Health(ctx context.Context) error {
+	_, err := c.raw(ctx, http.MethodGet, "/health", nil)
+	return err
+}
+
+// Styles lists the renderer themes.
+func (c *Client) Styles(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Styles []string `json:"styles"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/styles", nil, &resp)
+	return resp.Styles, err
+}
+
+// Execute executes a circuit and renders it.
+func (c *Client) Execute(ctx context.Context, req *CircuitRequest) (*CircuitResponse, error) {
+	var resp CircuitResponse
+	if err := c.do(ctx, http.MethodPost, "/api/execute", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExecuteBatch executes several circuits in one request. Circuits that fail
+// do not fail the request; their BatchItem carries the error.
+func (c *Client) ExecuteBatch(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	var resp BatchResponse
+	if err := c.do(ctx, http.MethodPost, "/api/execute/batch", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExecuteStream executes a circuit like Execute, calling progress (when not
+// nil) with the snapshots the service streams while the shots run. The
+// response carries no circuit image.
+func (c *Client) ExecuteStream(ctx context.Context, req *CircuitRequest, progress func(Progress)) (*CircuitResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.newRequest(ctx, http.MethodPost, "/api/execute/stream", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "text/event-stream")
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, readError(resp)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var event string
+	var data bytes.Buffer
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(line[len("event:"):])
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(line[len("data:"):], " "))
+		case line == "":
+			switch event {
+			case "progress":
+				var p Progress
+				if err := json.Unmarshal(data.Bytes(), &p); err != nil {
+					return nil, fmt.Errorf("qplay: decoding progress event: %w", err)
+				}
+				if progress != nil {
+					progress(p)
+				}
+			case "result":
+				var res CircuitResponse
+				if err := json.Unmarshal(data.Bytes(), &res); err != nil {
+					return nil, fmt.Errorf("qplay: decoding result event: %w", err)
+				}
+				return &res, nil
+			case "error":
+				// the stream has already started with 200
+				return nil, decodeError(http.StatusInternalServerError, data.Bytes())
+			}
+			event = ""
+			data.Reset()
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("qplay: stream ended without a result")
+}
+
+// Plot renders a plot of the given kind (histogram, statevector or bloch)
+// and returns the PNG or SVG document.
+func (c *Client) Plot(ctx context.Context, kind string, req *PlotRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.raw(ctx, http.MethodPost, "/api/plots/"+url.PathEscape(kind), body)
+}
+
+// Backends lists the registered backends.
+func (c *Client) Backends(ctx context.Context) (*BackendList, error) {
+	var list BackendList
+	if err := c.do(ctx, http.MethodGet, "/api/backends", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Backend describes one backend.
+func (c *Client) Backend(ctx context.Context, name string) (*Backend, error) {
+	var b Backend
+	if err := c.do(ctx, http.MethodGet, "/api/backends/"+url.PathEscape(name), nil, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// CacheStats returns the counters of the result cache.
+func (c *Client) CacheStats(ctx context.Context) (*CacheStats, error) {
+	var s CacheStats
+	if err := c.do(ctx, http.MethodGet, "/api/cache", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Metrics returns the service metrics in the Prometheus text format.
+func (c *Client) Metrics(ctx context.Context) (string, error) {
+	b, err := c.raw(ctx, http.MethodGet, "/metrics", nil)
+	return string(b), err
+}
+
+// SubmitJob queues a circuit execution.
+func (c *Client) SubmitJob(ctx context.Context, req *CircuitRequest) (*Job, error) {
+	return c.job(ctx, http.MethodPost, "/api/jobs", req)
+}
+
+// Job returns the status of a job.
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	return c.job(ctx, http.MethodGet, "/api/jobs/"+url.PathEscape(id), nil)
+}
+
+// CancelJob cancels a job; finished jobs are returned unchanged.
+func (c *Client) CancelJob(ctx context.Context, id string) (*Job, error) {
+	return c.job(ctx, http.MethodDelete, "/api/jobs/"+url.PathEscape(id), nil)
+}
+
+// Jobs lists the retained jobs, oldest first.
+func (c *Client) Jobs(ctx context.Context) ([]*Job, error) {
+	var resp struct {
+		Jobs []*Job `json:"jobs"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/jobs", nil, &resp)
+	return resp.Jobs, err
+}
+
+// WaitJob polls a job every interval until it finishes or ctx is done.
+func (c *Client) WaitJob(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		j, err := c.Job(ctx, id)
+		if err != nil || j.State.Finished() {
+			return j, err
+		}
+		select {
+		case <-ctx.Done():
+			return j, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Client) job(ctx context.Context, method, path string, in any) (*Job, error) {
+	var j Job
+	if err := c.do(ctx, method, path, in, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// CreateProgram stores a program and returns its ID.
+func (c *Client) CreateProgram(ctx context.Context, p *ProgramValue) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/qprogs", p, &resp)
+	return resp.ID, err
+}
+
+// Programs lists one page of stored programs, oldest first; limit 0 selects
+// the default page size.
+func (c *Client) Programs(ctx context.Context, offset, limit int) (*ProgramList, error) {
+	q := url.Values{}
+	q.Set("offset", strconv.Itoa(offset))
+	q.Set("limit", strconv.Itoa(limit))
+	var list ProgramList
+	if err := c.do(ctx, http.MethodGet, "/api/qprogs?"+q.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Program returns a stored program.
+func (c *Client) Program(ctx context.Context, id string) (*Program, error) {
+	return c.program(ctx, http.MethodGet, id, nil)
+}
+
+// UpdateProgram replaces a stored program.
+func (c *Client) UpdateProgram(ctx context.Context, id string, p *ProgramValue) (*Program, error) {
+	return c.program(ctx, http.MethodPut, id, p)
+}
+
+// DeleteProgram deletes a stored program.
+func (c *Client) DeleteProgram(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/api/qprogs/"+url.PathEscape(id), nil, nil)
+}
+
+// RenderProgram returns a PNG image of the circuit of a stored program.
+func (c *Client) RenderProgram(ctx context.Context, id string) ([]byte, error) {
+	return c.raw(ctx, http.MethodGet, "/api/qprogs/"+url.PathEscape(id)+"/img", nil)
+}
+
+func (c *Client) program(ctx context.Context, method, id string, in any) (*Program, error) {
+	var p Program
+	if err := c.do(ctx, method, "/api/qprogs/"+url.PathEscape(id), in, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// do sends in as JSON (unless nil) and decodes the response into out
+// (unless nil).
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	b, err := c.raw(ctx, method, path, body)
+	if err != nil || out == nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return fmt.Errorf("qplay: decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// raw sends body as JSON (unless nil) and returns the response body.
+func (c *Client) raw(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	r, err := c.newRequest(ctx, method, path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, readError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		r.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return r, nil
+}
+
+func readError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	return decodeError(resp.StatusCode, b)
+}
+
+// decodeError turns an error body, {"error": ..., "gate": ...} or plain
+// text, into an APIError.
+func decodeError(status int, body []byte) error {
+	e := &APIError{StatusCode: status, Gate: -1}
+	var payload struct {
+		Error string `json:"error"`
+		Gate  *int   `json:"gate"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error != "" {
+		e.Message = payload.Error
+		if payload.Gate != nil {
+			e.Gate = *payload.Gate
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	return e
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"missing or invalid API key"}`))
+		case r.URL.Path == "/api/execute":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"Invalid circuit: gates[2]: unknown gate","gate":2}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Internal Server Error - please contact the administrator\n"))
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	var apiErr *APIError
+	_, err := New(srv.URL).Styles(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, -1, apiErr.Gate)
+
+	c := New(srv.URL+"/", WithAPIKey("secret"))
+	_, err = c.Execute(ctx, &CircuitRequest{})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 2, apiErr.Gate)
+	assert.EqualError(t, err, "qplay: 400: Invalid circuit: gates[2]: unknown gate (gate 2)")
+
+	_, err = c.CacheStats(ctx)
+	assert.EqualError(t, err, "qplay: 500: Internal Server Error - please contact the administrator", "plain text bodies")
+	assert.False(t, IsNotFound(err))
+}
+
+func TestClient_ExecuteStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CircuitRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event:progress\ndata:{\"completed\":5,\"total\":10,\"histogram\":{\"0\":5}}\n\n" +
+			"event:progress\ndata:{\"completed\":10,\"total\":10,\"histogram\":{\"0\":10}}\n\n"))
+		if req.Backend == "broken" {
+			w.Write([]byte("event:error\ndata:{\"error\":\"Circuit execution failed: boom\"}\n\n"))
+			return
+		}
+		w.Write([]byte("event:result\ndata:{\"measurements\":{\"0\":10},\"backend\":\"qsim\",\"shots\":10,\"cached\":false}\n\n"))
+	}))
+	defer srv.Close()
+
+	var seen []int
+	res, err := New(srv.URL).ExecuteStream(context.Background(), &CircuitRequest{}, func(p Progress) {
+		seen = append(seen, p.Completed)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{5, 10}, seen)
+	assert.Equal(t, map[string]int{"0": 10}, res.Measurements)
+
+	_, err = New(srv.URL).ExecuteStream(context.Background(), &CircuitRequest{Backend: "broken"}, nil)
+	assert.ErrorContains(t, err, "boom")
+}
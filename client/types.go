@@ -0,0 +1,154 @@
+package client
+
+import (
+	"time"
+
+	"github.com/kegliz/qplay/qc/simulator"
+	"github.com/kegliz/qplay/qc/spec"
+)
+
+type (
+	// CircuitRequest asks the service to execute a circuit.
+	CircuitRequest struct {
+		Circuit spec.Circuit `json:"circuit"`
+		Backend string       `json:"backend"` // "" selects the default backend
+		Shots   int          `json:"shots"`   // out-of-range values fall back to the default
+		// Style names the renderer theme of the circuit image (see Styles).
+		Style string `json:"style"`
+		// Seed tells apart requests that should not share cached results.
+		Seed int64 `json:"seed,omitempty"`
+		// NoCache runs the circuit even when a cached result exists.
+		NoCache bool `json:"no_cache,omitempty"`
+	}
+
+	// CircuitResponse is the result of an execution.
+	CircuitResponse struct {
+		Measurements  map[string]int `json:"measurements,omitempty"`
+		StateVector   []complex128   `json:"state_vector,omitempty"`
+		CircuitImage  string         `json:"circuit_image,omitempty"` // base64-encoded PNG
+		ExecutionTime float64        `json:"execution_time,omitempty"`
+		Backend       string         `json:"backend"`
+		Shots         int            `json:"shots"`
+		Cached        bool           `json:"cached"`
+	}
+
+	// BatchRequest asks the service to execute several circuits on the same
+	// backend for the same number of shots.
+	BatchRequest struct {
+		Circuits []spec.Circuit `json:"circuits"`
+		Backend  string         `json:"backend"`
+		Shots    int            `json:"shots"`
+	}
+
+	// BatchItem is the outcome of one circuit of a batch.
+	BatchItem struct {
+		Measurements map[string]int `json:"measurements,omitempty"`
+		Error        string         `json:"error,omitempty"`
+		Gate         *int           `json:"gate,omitempty"` // index of the gate Error is about
+	}
+
+	// BatchResponse holds one result per circuit, in request order.
+	BatchResponse struct {
+		Results       []BatchItem `json:"results"`
+		ExecutionTime float64     `json:"execution_time"` // seconds
+		Backend       string      `json:"backend"`
+		Shots         int         `json:"shots"`
+	}
+
+	// PlotRequest asks for a plot. Histograms plot Counts when given and
+	// otherwise execute the circuit.
+	PlotRequest struct {
+		CircuitRequest
+		Counts map[string]int     `json:"counts,omitempty"`
+		Ideal  map[string]float64 `json:"ideal,omitempty"`
+		Format string             `json:"format,omitempty"` // "png" (default) or "svg"
+	}
+
+	// Progress is a snapshot sent while a streamed execution runs.
+	Progress struct {
+		Completed int            `json:"completed"`
+		Total     int            `json:"total"`
+		Histogram map[string]int `json:"histogram"`
+	}
+
+	// Backend describes a simulator backend.
+	Backend struct {
+		Name    string `json:"name"`
+		Default bool   `json:"default"`
+		// MaxQubits is the largest circuit the backend accepts.
+		MaxQubits int `json:"max_qubits"`
+		// Capabilities tells which optional runner interfaces are implemented.
+		Capabilities   map[string]bool             `json:"capabilities"`
+		Info           *simulator.BackendInfo      `json:"info,omitempty"`
+		SupportedGates []string                    `json:"supported_gates,omitempty"`
+		Metrics        *simulator.ExecutionMetrics `json:"metrics,omitempty"`
+	}
+
+	// BackendList lists every registered backend.
+	BackendList struct {
+		Default  string    `json:"default"`
+		Backends []Backend `json:"backends"`
+	}
+
+	// CacheStats are the counters of the result cache.
+	CacheStats struct {
+		Hits    int64 `json:"hits"`
+		Misses  int64 `json:"misses"`
+		Entries int   `json:"entries"`
+		Size    int   `json:"size"`
+	}
+
+	// JobState is the lifecycle state of a job.
+	JobState string
+
+	// Job is the status of a background job.
+	Job struct {
+		ID         string         `json:"id"`
+		State      JobState       `json:"state"`
+		Backend    string         `json:"backend"`
+		Shots      int            `json:"shots"`
+		Completed  int            `json:"completed"` // shots run so far
+		Progress   float64        `json:"progress"`  // Completed/Shots
+		Result     map[string]int `json:"result,omitempty"`
+		Error      string         `json:"error,omitempty"`
+		CreatedAt  time.Time      `json:"created_at"`
+		StartedAt  *time.Time     `json:"started_at,omitempty"`
+		FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	}
+
+	// ProgramValue is the user-supplied part of a stored program.
+	ProgramValue struct {
+		Name    string       `json:"name,omitempty"`
+		Circuit spec.Circuit `json:"circuit"`
+	}
+
+	// Program is a stored program.
+	Program struct {
+		ID string `json:"id"`
+		ProgramValue
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// ProgramList is one page of stored programs.
+	ProgramList struct {
+		Programs []*Program `json:"programs"`
+		Total    int        `json:"total"`
+		Offset   int        `json:"offset"`
+		Limit    int        `json:"limit"`
+	}
+)
+
+// Job states.
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Finished reports whether the job has reached a final state.
+func (s JobState) Finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"os"
 	"sort" // Import the sort package
 
 	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/renderer"
 	"github.com/kegliz/qplay/qc/simulator"
 	"github.com/kegliz/qplay/qc/simulator/itsu"
 )
 
+// diagram prints the circuits before they are simulated.
+var diagram renderer.Text
+
 func main() {
+	flag.IntVar(&diagram.Width, "width", 80, "fold circuit diagrams at this many columns (0 disables folding)")
+	flag.BoolVar(&diagram.ASCII, "ascii", false, "draw circuit diagrams with plain ASCII characters")
+	flag.Parse()
+
 	shots := 1024
 
 	fmt.Println("--- Bell State Simulation ---")
@@ -18,6 +30,8 @@ func main() {
 	simulateGrover2Qubit(shots)
 	fmt.Println("\n--- 3-Qubit Grover Simulation (|111>) ---")
 	simulateGrover3Qubit(shots)
+	fmt.Println("\n--- 3-Qubit Grover Iteration Sweep (|111>) ---")
+	sweepGrover3Qubit(shots)
 }
 
 // simulateBellState prepares the |Φ⁺⟩ Bell state and checks ~50/50 statistics.
@@ -30,6 +44,7 @@ func simulateBellState(shots int) {
 		fmt.Printf("Error building Bell state circuit: %v\n", err)
 		return
 	}
+	printCircuit(c)
 
 	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: shots, Runner: itsu.NewItsuOneShotRunner()})
 	hist, err := sim.Run(c)
@@ -67,6 +82,7 @@ func simulateGrover2Qubit(shots int) {
 		fmt.Printf("Error building 2-qubit Grover circuit: %v\n", err)
 		return
 	}
+	printCircuit(c)
 
 	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: shots, Runner: itsu.NewItsuOneShotRunner()})
 	hist, err := sim.Run(c)
@@ -108,6 +124,7 @@ func simulateGrover3Qubit(shots int) {
 		fmt.Printf("Error building 3-qubit Grover circuit: %v\n", err)
 		return
 	}
+	printCircuit(c)
 
 	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: shots, Runner: itsu.NewItsuOneShotRunner()})
 	hist, err := sim.Run(c)
@@ -119,6 +136,45 @@ func simulateGrover3Qubit(shots int) {
 	pretty(hist, shots)
 }
 
+// sweepGrover3Qubit runs the 3-qubit Grover search with 0 to 4 iterations in
+// one batch and prints how often |111⟩ is found. The success probability
+// peaks at two iterations and falls again when the search overshoots.
+func sweepGrover3Qubit(shots int) {
+	grover := func(p simulator.Params) (circuit.Circuit, error) {
+		b := builder.New(builder.Q(3), builder.C(3))
+		b.H(0).H(1).H(2)
+		for range int(p["iterations"]) {
+			// oracle (CCZ) and diffusion, as in simulateGrover3Qubit
+			b.H(2).Toffoli(0, 1, 2).H(2)
+			b.H(0).H(1).H(2)
+			b.X(0).X(1).X(2)
+			b.H(2).Toffoli(0, 1, 2).H(2)
+			b.X(0).X(1).X(2)
+			b.H(0).H(1).H(2)
+		}
+		b.Measure(0, 0).Measure(1, 1).Measure(2, 2)
+		return b.BuildCircuit()
+	}
+
+	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: shots, Runner: itsu.NewItsuOneShotRunner()})
+	points := simulator.Grid(map[string][]float64{"iterations": {0, 1, 2, 3, 4}})
+	for _, res := range sim.Sweep(context.Background(), grover, points) {
+		if res.Err != nil {
+			fmt.Printf("Error running %v iterations: %v\n", res.Params["iterations"], res.Err)
+			continue
+		}
+		probability := float64(res.Counts["111"]) / float64(shots)
+		fmt.Printf("Iterations %v: |111> in %d counts (%.2f%%)\n", res.Params["iterations"], res.Counts["111"], probability*100)
+	}
+}
+
+// printCircuit draws the circuit as text on stdout.
+func printCircuit(c circuit.Circuit) {
+	if err := diagram.Fprint(os.Stdout, c); err != nil {
+		fmt.Printf("Error drawing circuit: %v\n", err)
+	}
+}
+
 // pretty prints the histogram results in a readable, sorted format
 func pretty(hist map[string]int, shots int) {
 	// Extract keys for sorting
@@ -12,6 +12,9 @@ import (
 
 	// Import the itsu package to register the plugin
 	_ "github.com/kegliz/qplay/qc/simulator/itsu"
+	// Import the remote package to register the "remote" plugin; it reads
+	// its workers from QPLAY_REMOTE_WORKERS
+	_ "github.com/kegliz/qplay/qc/simulator/remote"
 )
 
 func main() {
@@ -0,0 +1,70 @@
+// Command qplay-worker runs circuits for a remote coordinator: a Simulator
+// using the "remote" backend (package qc/simulator/remote) splits its shots
+// across workers and merges their histograms.
+//
+//	qplay-worker -addr :8081 -backend qsim
+//	QPLAY_REMOTE_WORKERS=http://host1:8081,http://host2:8081 plugin-demo run remote
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kegliz/qplay/qc/simulator/remote"
+
+	// Import simulators to register them
+	_ "github.com/kegliz/qplay/qc/simulator/itsu"
+	_ "github.com/kegliz/qplay/qc/simulator/qsim"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "listen address")
+	backend := flag.String("backend", "qsim", "backend to simulate with")
+	workers := flag.Int("workers", 0, "goroutines per request (0 = number of CPUs)")
+	maxQubits := flag.Int("maxqubits", 20, "largest circuit accepted (0 = no limit)")
+	maxShots := flag.Int("maxshots", 0, "most shots per request (0 = no limit)")
+	flag.Parse()
+
+	if err := workerMain(*addr, remote.WorkerOptions{
+		Backend:   *backend,
+		Workers:   *workers,
+		MaxQubits: *maxQubits,
+		MaxShots:  *maxShots,
+	}); err != nil {
+		log.Fatalf("error: %+v", err)
+	}
+}
+
+// workerMain serves the worker until SIGINT or SIGTERM.
+func workerMain(addr string, opts remote.WorkerOptions) error {
+	w, err := remote.NewWorker(opts)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, Handler: w}
+	go func() {
+		log.Printf("qplay-worker (%s) listening on %s", opts.Backend, addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Listen: %s\n", err)
+		}
+	}()
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down worker...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	m := w.Metrics()
+	log.Printf("Worker exited gracefully after %d shots", m.TotalExecutions)
+	return nil
+}
@@ -33,6 +33,9 @@ func appMain() error {
 	if err != nil {
 		return err
 	}
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
 
 	srv, err := app.NewServer(app.ServerOptions{
 		C:       conf,
@@ -3,11 +3,19 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kegliz/qplay/internal/config"
+	"github.com/kegliz/qplay/internal/jobs"
 	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/internal/metrics"
+	"github.com/kegliz/qplay/internal/qservice"
+	"github.com/kegliz/qplay/internal/resultcache"
 	"github.com/kegliz/qplay/internal/server/router"
 
 	"github.com/kegliz/qplay/internal/server"
@@ -20,27 +28,45 @@ type (
 	}
 
 	appServer struct {
-		logger *logger.Logger
-		router *router.Router
-		//qs      qservice.Service
-		version string
+		logger   *logger.Logger
+		router   *router.Router
+		qs       qservice.Service
+		jobs     *jobs.Manager
+		backends *backends
+		cache    *resultcache.Cache
+		registry *metrics.Registry
+		metrics  *appMetrics
+		limits   limits
+		version  string
 	}
 
 	appServerOptions struct {
-		logger *logger.Logger
-		router *router.Router
-		//qs      qservice.Service
-		version string
+		logger   *logger.Logger
+		router   *router.Router
+		qs       qservice.Service
+		jobs     *jobs.Manager
+		backends *backends
+		cache    *resultcache.Cache
+		registry *metrics.Registry
+		metrics  *appMetrics
+		limits   limits
+		version  string
 	}
 )
 
 // newAppServer creates a new appServer.
 func newAppServer(options appServerOptions) *appServer {
 	a := &appServer{
-		logger: options.logger,
-		router: options.router,
-		//qs:      options.qs,
-		version: options.version,
+		logger:   options.logger,
+		router:   options.router,
+		qs:       options.qs,
+		jobs:     options.jobs,
+		backends: options.backends,
+		cache:    options.cache,
+		registry: options.registry,
+		metrics:  options.metrics,
+		limits:   options.limits,
+		version:  options.version,
 	}
 	a.router.SetRoutes(a.routes())
 	return a
@@ -59,27 +85,152 @@ func (a *appServer) Listen(port int, localOnly bool) error {
 
 // Shutdown implements server.Server.
 func (a *appServer) Shutdown(ctx context.Context) error {
-	return a.router.Shutdown(ctx)
+	err := a.router.Shutdown(ctx)
+	a.jobs.Close()
+	return err
 }
 
 func NewServer(options ServerOptions) (server.Server, error) {
+	auth, err := authOptions(options.C)
+	if err != nil {
+		return nil, err
+	}
+	lim, err := newLimits(options.C)
+	if err != nil {
+		return nil, err
+	}
+	reg := metrics.NewRegistry()
 	l, r := server.NewLoggerAndRouter(server.EngineOptions{
-		Debug: options.C.GetBool("debug"),
+		Debug:     options.C.GetBool("debug"),
+		Auth:      auth,
+		Metrics:   reg,
+		StaticDir: options.C.GetString("staticdir"),
+	})
+	if err := loadTemplates(r, options.C.GetString("templatefolder")); err != nil {
+		return nil, err
+	}
+	store, err := newProgramStore(options.C)
+	if err != nil {
+		return nil, err
+	}
+	qs := qservice.NewService(qservice.ServiceOptions{
+		Logger:    l,
+		Store:     store,
+		CellSize:  lim.cellSize,
+		MaxQubits: lim.maxQubits,
+	})
+	bs := newBackends(lim)
+	var am *appMetrics // set before any job can finish
+	jm := jobs.NewManager(jobs.ManagerOptions{
+		Logger:    l,
+		Pool:      bs.pool,
+		OnFinish:  func(s jobs.Status) { am.job(s) },
+		Workers:   options.C.GetInt("jobworkers"),
+		QueueSize: options.C.GetInt("jobqueuesize"),
+		Retention: time.Duration(options.C.GetInt("jobretention")) * time.Second,
 	})
-	// qs := qservice.NewService(qservice.ServiceOptions{
-	// 	Logger: l,
-	// 	Store:  qservice.NewProgramStore(),
-	// })
+	rc := resultcache.New(resultcache.Options{
+		Size: options.C.GetInt("resultcachesize"),
+		TTL:  time.Duration(options.C.GetInt("resultcachettl")) * time.Second,
+	})
+	am = newAppMetrics(reg, bs, rc, jm)
 	app := newAppServer(appServerOptions{
-		logger: l,
-		router: r,
-		//qs:      qs,
-		version: options.Version,
+		logger:   l,
+		router:   r,
+		qs:       qs,
+		jobs:     jm,
+		backends: bs,
+		cache:    rc,
+		registry: reg,
+		metrics:  am,
+		limits:   lim,
+		version:  options.Version,
 	})
 
 	return app, nil
 }
 
+// authOptions reads the API keys, rate limit and default quota. apikeys is
+// either a comma-separated list of keys or, in the config file, a list of
+// entries with key, name, ratelimit, maxshots and maxqubits fields.
+func authOptions(c *config.Config) (router.AuthOptions, error) {
+	opts := router.AuthOptions{
+		RateLimit: c.GetInt("ratelimit"),
+		Burst:     c.GetInt("rateburst"),
+		Quota: router.Quota{
+			MaxShots:  c.GetInt("quotamaxshots"),
+			MaxQubits: c.GetInt("quotamaxqubits"),
+		},
+		Public: []string{"/", "/health", "/static", "/api/openapi.json"},
+	}
+	switch v := c.Get("apikeys").(type) {
+	case nil:
+	case string:
+		for i, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				opts.Keys = append(opts.Keys, router.APIKey{Key: k, Name: fmt.Sprintf("key%d", i+1)})
+			}
+		}
+	default:
+		var entries []struct {
+			Key       string
+			Name      string
+			RateLimit int
+			MaxShots  int
+			MaxQubits int
+		}
+		if err := c.UnmarshalKey("apikeys", &entries); err != nil {
+			return opts, fmt.Errorf("reading apikeys: %w", err)
+		}
+		for i, e := range entries {
+			if e.Key == "" {
+				return opts, fmt.Errorf("apikeys[%d] has no key", i)
+			}
+			if e.Name == "" {
+				e.Name = fmt.Sprintf("key%d", i+1)
+			}
+			opts.Keys = append(opts.Keys, router.APIKey{
+				Key:       e.Key,
+				Name:      e.Name,
+				RateLimit: e.RateLimit,
+				Quota:     router.Quota{MaxShots: e.MaxShots, MaxQubits: e.MaxQubits},
+			})
+		}
+	}
+	return opts, nil
+}
+
+// loadTemplates loads the HTML templates of the web UI from folder; an empty
+// folder leaves the UI without templates.
+func loadTemplates(r *router.Router, folder string) error {
+	if folder == "" {
+		return nil
+	}
+	pattern := filepath.Join(folder, "*.tmpl")
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("templatefolder: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("templatefolder: no templates match %s", pattern)
+	}
+	r.LoadHTMLFiles(files...)
+	return nil
+}
+
+// newProgramStore selects the program store configured by qprogstore:
+// "memory" (the default) or "file", which writes to qprogdir.
+func newProgramStore(c *config.Config) (qservice.ProgramStore, error) {
+	switch kind := c.GetString("qprogstore"); kind {
+	case "", "memory":
+		return qservice.NewProgramStore(), nil
+	case "file":
+		return qservice.NewFileProgramStore(c.GetString("qprogdir"))
+	default:
+		return nil, fmt.Errorf("unknown program store %q (memory or file)", kind)
+	}
+}
+
 func (a *appServer) getLoggerFromContext(c *gin.Context) (*logger.Logger, error) {
 	if loggerInstance, ok := c.Get("logger"); ok {
 		if loggerInstance, ok := loggerInstance.(*logger.Logger); ok {
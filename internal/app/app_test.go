@@ -3,15 +3,30 @@ package app
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/kegliz/qplay/client"
 	"github.com/kegliz/qplay/internal/config"
+	"github.com/kegliz/qplay/internal/jobs"
+	"github.com/kegliz/qplay/internal/qservice"
+	"github.com/kegliz/qplay/internal/resultcache"
 	"github.com/kegliz/qplay/internal/server"
 	"github.com/kegliz/qplay/internal/server/router"
+	"github.com/kegliz/qplay/qc/simulator"
+	"github.com/kegliz/qplay/qc/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -80,6 +95,529 @@ func (s *AppServerTestSuite) TestHealthHandler() {
 	s.Contains(rec.Body.String(), "OK", "200 GET /health")
 }
 
+// test /api/styles endpoint handler
+func (s *AppServerTestSuite) TestListStyles() {
+	rec := s.doRequest(http.MethodGet, "/api/styles", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/styles")
+	s.Contains(rec.Body.String(), `"dark"`, "200 GET /api/styles")
+}
+
+// test /api/backends endpoint handlers
+func (s *AppServerTestSuite) TestBackends() {
+	rec := s.doRequest(http.MethodGet, "/api/backends", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/backends")
+	var list BackendListResponse
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &list))
+	s.Equal("qsim", list.Default, "200 GET /api/backends")
+	var names []string
+	for _, b := range list.Backends {
+		names = append(names, b.Name)
+	}
+	s.Subset(names, []string{"itsu", "qsim"}, "200 GET /api/backends")
+
+	// metrics accumulate on the shared runner
+	body := `{"circuit":{"qubits":1,"gates":[]},"backend":"itsu","shots":7}`
+	rec = s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute")
+
+	rec = s.doRequest(http.MethodGet, "/api/backends/itsu", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/backends/:name")
+	var itsu BackendResponse
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &itsu))
+	s.True(itsu.Capabilities["metrics"], "200 GET /api/backends/:name")
+	s.Contains(itsu.SupportedGates, "H", "200 GET /api/backends/:name")
+	s.NotNil(itsu.Info, "200 GET /api/backends/:name")
+	if s.NotNil(itsu.Metrics, "200 GET /api/backends/:name") {
+		s.GreaterOrEqual(itsu.Metrics.TotalExecutions, int64(7), "200 GET /api/backends/:name")
+	}
+
+	rec = s.doRequest(http.MethodGet, "/api/backends/nope", nil, "")
+	s.Equal(http.StatusNotFound, rec.Code, "404 GET /api/backends/:name")
+}
+
+// test /api/execute result caching and the /api/cache endpoint handler
+func (s *AppServerTestSuite) TestExecuteCircuitCache() {
+	var before, after resultcache.Stats
+	rec := s.doRequest(http.MethodGet, "/api/cache", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/cache")
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &before))
+
+	execute := func(body string) CircuitResponse {
+		rec := s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+		s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute")
+		var resp CircuitResponse
+		s.NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+	// Independent gates listed in a different order hash alike.
+	first := execute(`{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0]},{"type":"H","qubits":[1]}]},"shots":64,"seed":42}`)
+	s.False(first.Cached, "200 POST /api/execute")
+	second := execute(`{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[1]},{"type":"H","qubits":[0]}]},"shots":64,"seed":42}`)
+	s.True(second.Cached, "200 POST /api/execute")
+	s.Equal(first.Measurements, second.Measurements, "200 POST /api/execute")
+	bypass := execute(`{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0]},{"type":"H","qubits":[1]}]},"shots":64,"seed":42,"no_cache":true}`)
+	s.False(bypass.Cached, "200 POST /api/execute")
+	reseeded := execute(`{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0]},{"type":"H","qubits":[1]}]},"shots":64,"seed":7}`)
+	s.False(reseeded.Cached, "200 POST /api/execute")
+
+	rec = s.doRequest(http.MethodGet, "/api/cache", nil, "")
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &after))
+	s.Equal(before.Hits+1, after.Hits, "200 GET /api/cache")
+	s.Equal(before.Misses+2, after.Misses, "200 GET /api/cache")
+}
+
+// test /api/execute endpoint handler with a renderer style
+func (s *AppServerTestSuite) TestExecuteCircuitStyle() {
+	body := `{"circuit":{"qubits":1,"gates":[{"type":"H","qubits":[0],"step":0}]},"shots":10,"style":"dark"}`
+	rec := s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute")
+	s.Contains(rec.Body.String(), `"circuit_image"`, "200 POST /api/execute")
+
+	body = `{"circuit":{"qubits":1,"gates":[]},"style":"neon"}`
+	rec = s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute")
+	s.Contains(rec.Body.String(), "neon", "400 POST /api/execute")
+}
+
+// test /api/execute endpoint handler with the full circuit schema
+func (s *AppServerTestSuite) TestExecuteCircuitSchema() {
+	body := `{"circuit":{"qubits":2,"clbits":1,"gates":[{"type":"x","qubits":[1],"step":15},{"type":"MEASURE","qubits":[1],"clbits":[0],"step":16}]},"shots":10}`
+	rec := s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute")
+	s.Contains(rec.Body.String(), `"measurements":{"1":10}`, "200 POST /api/execute")
+
+	body = `{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0]},{"type":"TOFFOLI","qubits":[0,1]}]}}`
+	rec = s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute")
+	s.Contains(rec.Body.String(), `"gate":1`, "400 POST /api/execute")
+	s.Contains(rec.Body.String(), "gates[1]: TOFFOLI needs 3 qubit(s)", "400 POST /api/execute")
+
+	body = `{"circuit":{"qubits":11,"gates":[]}}`
+	rec = s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute")
+	s.Contains(rec.Body.String(), "exceeds the limit of 10", "400 POST /api/execute")
+}
+
+// test /metrics endpoint handler
+func (s *AppServerTestSuite) TestMetrics() {
+	body := `{"circuit":{"qubits":1,"gates":[{"type":"X","qubits":[0]}]},"backend":"itsu","shots":5,"no_cache":true}`
+	rec := s.doRequest(http.MethodPost, "/api/execute", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute")
+
+	rec = s.doRequest(http.MethodGet, "/metrics", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /metrics")
+	s.Contains(rec.Header().Get("Content-Type"), "version=0.0.4", "200 GET /metrics")
+	out := rec.Body.String()
+	for _, want := range []string{
+		`qplay_http_requests_total{route="/api/execute",method="POST",status="200"} `,
+		`qplay_http_request_duration_seconds_bucket{route="/api/execute",method="POST",status="200",le="+Inf"} `,
+		`qplay_simulations_total{backend="itsu",outcome="ok"} `,
+		`qplay_shots_total{backend="itsu"} `,
+		"qplay_job_queue_depth 0\n",
+		`qplay_result_cache_requests_total{result="miss"} `,
+		`qplay_runner_runs_total{backend="itsu",outcome="success"} `,
+	} {
+		s.Contains(out, want, "200 GET /metrics")
+	}
+}
+
+// test /api/plots/:kind endpoint handler
+func (s *AppServerTestSuite) TestPlotCircuit() {
+	rec := s.doRequest(http.MethodPost, "/api/plots/histogram", strings.NewReader(`{"counts":{"00":5,"11":5},"ideal":{"00":0.5,"11":0.5}}`), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/plots/histogram")
+	s.Equal("image/png", rec.Header().Get("Content-Type"), "200 POST /api/plots/histogram")
+
+	bell := `{"circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0],"step":0},{"type":"CNOT","qubits":[0,1],"step":1}]},"format":"svg"}`
+	for _, kind := range []string{"histogram", "statevector", "bloch"} {
+		rec = s.doRequest(http.MethodPost, "/api/plots/"+kind, strings.NewReader(bell), "application/json")
+		s.Equal(http.StatusOK, rec.Code, "200 POST /api/plots/"+kind)
+		s.Equal("image/svg+xml", rec.Header().Get("Content-Type"), "200 POST /api/plots/"+kind)
+		s.Contains(rec.Body.String(), "<svg ", "200 POST /api/plots/"+kind)
+	}
+
+	onItsu := strings.Replace(bell, `"format"`, `"backend":"itsu","format"`, 1)
+	rec = s.doRequest(http.MethodPost, "/api/plots/statevector", strings.NewReader(onItsu), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/plots/statevector")
+	s.Contains(rec.Body.String(), "does not support state vectors", "400 POST /api/plots/statevector")
+
+	rec = s.doRequest(http.MethodPost, "/api/plots/pie", strings.NewReader(bell), "application/json")
+	s.Equal(http.StatusNotFound, rec.Code, "404 POST /api/plots/pie")
+
+	rec = s.doRequest(http.MethodPost, "/api/plots/histogram", strings.NewReader(`{"format":"gif","counts":{"0":1}}`), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/plots/histogram")
+}
+
+// test /api/qprogs endpoint handlers
+func (s *AppServerTestSuite) TestQProgs() {
+	bell := `{"name":"bell","circuit":{"qubits":2,"gates":[{"type":"H","qubits":[0],"step":0},{"type":"CNOT","qubits":[0,1],"step":1}]}}`
+	rec := s.doRequest(http.MethodPost, "/api/qprogs", strings.NewReader(bell), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/qprogs")
+	var created struct{ ID string }
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	s.NotEmpty(created.ID, "200 POST /api/qprogs")
+
+	rec = s.doRequest(http.MethodGet, "/api/qprogs/"+created.ID, nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/qprogs/:id")
+	s.Contains(rec.Body.String(), `"name":"bell"`, "200 GET /api/qprogs/:id")
+
+	rec = s.doRequest(http.MethodGet, "/api/qprogs/"+created.ID+"/img", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/qprogs/:id/img")
+	s.Equal("image/png", rec.Header().Get("Content-Type"), "200 GET /api/qprogs/:id/img")
+	_, err := png.Decode(rec.Body)
+	s.NoError(err, "200 GET /api/qprogs/:id/img")
+
+	renamed := strings.Replace(bell, `"bell"`, `"bell pair"`, 1)
+	rec = s.doRequest(http.MethodPut, "/api/qprogs/"+created.ID, strings.NewReader(renamed), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 PUT /api/qprogs/:id")
+	s.Contains(rec.Body.String(), `"name":"bell pair"`, "200 PUT /api/qprogs/:id")
+
+	rec = s.doRequest(http.MethodGet, "/api/qprogs?limit=1", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/qprogs")
+	s.Contains(rec.Body.String(), `"limit":1`, "200 GET /api/qprogs")
+	s.Contains(rec.Body.String(), created.ID, "200 GET /api/qprogs")
+
+	rec = s.doRequest(http.MethodGet, "/api/qprogs?offset=x", nil, "")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 GET /api/qprogs")
+
+	rec = s.doRequest(http.MethodPost, "/api/qprogs", strings.NewReader(`{"circuit":{"qubits":0}}`), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/qprogs")
+
+	rec = s.doRequest(http.MethodDelete, "/api/qprogs/"+created.ID, nil, "")
+	s.Equal(http.StatusNoContent, rec.Code, "204 DELETE /api/qprogs/:id")
+	rec = s.doRequest(http.MethodGet, "/api/qprogs/"+created.ID+"/img", nil, "")
+	s.Equal(http.StatusNotFound, rec.Code, "404 GET /api/qprogs/:id/img")
+	rec = s.doRequest(http.MethodDelete, "/api/qprogs/"+created.ID, nil, "")
+	s.Equal(http.StatusNotFound, rec.Code, "404 DELETE /api/qprogs/:id")
+}
+
+// test /api/execute/stream endpoint handler
+func (s *AppServerTestSuite) TestExecuteCircuitStream() {
+	body := `{"circuit":{"qubits":1,"gates":[{"type":"X","qubits":[0],"step":0}]},"shots":500}`
+	rec := s.doRequest(http.MethodPost, "/api/execute/stream", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusOK, rec.Code, "200 POST /api/execute/stream")
+	s.Equal("text/event-stream", rec.Header().Get("Content-Type"), "200 POST /api/execute/stream")
+	out := rec.Body.String()
+	s.Contains(out, "event:progress\n", "200 POST /api/execute/stream")
+	s.Contains(out, "event:result\n", "200 POST /api/execute/stream")
+	s.Contains(out, `"measurements":{"1":500}`, "200 POST /api/execute/stream")
+	s.True(strings.LastIndex(out, "event:progress") < strings.Index(out, "event:result"), "result comes last")
+
+	rec = s.doRequest(http.MethodPost, "/api/execute/stream", strings.NewReader(strings.Replace(body, `"shots":500`, `"backend":"nope"`, 1)), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute/stream")
+}
+
+func (s *AppServerTestSuite) TestExecuteBatch() {
+	x := `{"qubits":1,"gates":[{"type":"X","qubits":[0],"step":0}]}`
+	rec := s.doRequest(http.MethodPost, "/api/execute/batch", strings.NewReader(`{"circuits":[`+x+`,{"qubits":1,"gates":[]}],"shots":40}`), "application/json")
+	s.Require().Equal(http.StatusOK, rec.Code, "200 POST /api/execute/batch")
+	var resp BatchResponse
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp), "200 POST /api/execute/batch")
+	s.Equal([]BatchItem{{Measurements: map[string]int{"1": 40}}, {Measurements: map[string]int{"0": 40}}}, resp.Results, "results in request order")
+	s.Equal("qsim", resp.Backend, "200 POST /api/execute/batch")
+
+	rec = s.doRequest(http.MethodPost, "/api/execute/batch", strings.NewReader(`{"circuits":[]}`), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute/batch")
+	many := strings.Repeat(x+",", 1000) + x
+	rec = s.doRequest(http.MethodPost, "/api/execute/batch", strings.NewReader(`{"circuits":[`+many+`],"shots":1000}`), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/execute/batch")
+	s.Contains(rec.Body.String(), "Too many shots in batch", "400 POST /api/execute/batch")
+}
+
+// test /api/jobs endpoint handlers
+func (s *AppServerTestSuite) TestJobs() {
+	body := `{"circuit":{"qubits":1,"gates":[{"type":"X","qubits":[0],"step":0}]},"shots":20}`
+	rec := s.doRequest(http.MethodPost, "/api/jobs", strings.NewReader(body), "application/json")
+	s.Equal(http.StatusAccepted, rec.Code, "202 POST /api/jobs")
+	var job struct {
+		ID     string
+		State  string
+		Result map[string]int
+	}
+	s.NoError(json.Unmarshal(rec.Body.Bytes(), &job))
+	s.NotEmpty(job.ID, "202 POST /api/jobs")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.State != "succeeded" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		rec = s.doRequest(http.MethodGet, "/api/jobs/"+job.ID, nil, "")
+		s.Equal(http.StatusOK, rec.Code, "200 GET /api/jobs/:id")
+		s.NoError(json.Unmarshal(rec.Body.Bytes(), &job))
+	}
+	s.Equal("succeeded", job.State, "200 GET /api/jobs/:id")
+	s.Equal(20, job.Result["1"], "200 GET /api/jobs/:id")
+
+	rec = s.doRequest(http.MethodGet, "/api/jobs", nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 GET /api/jobs")
+	s.Contains(rec.Body.String(), job.ID, "200 GET /api/jobs")
+
+	rec = s.doRequest(http.MethodDelete, "/api/jobs/"+job.ID, nil, "")
+	s.Equal(http.StatusOK, rec.Code, "200 DELETE /api/jobs/:id")
+	s.Contains(rec.Body.String(), `"state":"succeeded"`, "finished jobs stay finished")
+
+	rec = s.doRequest(http.MethodDelete, "/api/jobs/nope", nil, "")
+	s.Equal(http.StatusNotFound, rec.Code, "404 DELETE /api/jobs/:id")
+
+	rec = s.doRequest(http.MethodPost, "/api/jobs", strings.NewReader(strings.Replace(body, `"shots":20`, `"backend":"nope"`, 1)), "application/json")
+	s.Equal(http.StatusBadRequest, rec.Code, "400 POST /api/jobs")
+}
+
+// test the Go client against the server
+func (s *AppServerTestSuite) TestClient() {
+	srv := httptest.NewServer(s.TestAppServer.(*appServer).router)
+	defer srv.Close()
+	c := client.New(srv.URL)
+	ctx := context.Background()
+
+	bell := spec.Circuit{Qubits: 2, Gates: []spec.Gate{
+		{Type: "H", Qubits: []int{0}},
+		{Type: "CNOT", Qubits: []int{0, 1}, Step: 1},
+	}}
+	res, err := c.Execute(ctx, &client.CircuitRequest{Circuit: bell, Shots: 50, NoCache: true})
+	s.Require().NoError(err, "POST /api/execute")
+	s.Equal(50, res.Measurements["00"]+res.Measurements["11"], "POST /api/execute")
+	s.NotEmpty(res.CircuitImage, "POST /api/execute")
+
+	var snapshots int
+	res, err = c.ExecuteStream(ctx, &client.CircuitRequest{Circuit: bell, Shots: 200}, func(client.Progress) { snapshots++ })
+	s.Require().NoError(err, "POST /api/execute/stream")
+	s.Equal(200, res.Shots, "POST /api/execute/stream")
+
+	batch, err := c.ExecuteBatch(ctx, &client.BatchRequest{Circuits: []spec.Circuit{
+		bell,
+		{Qubits: 1, Gates: []spec.Gate{{Type: "CNOT", Qubits: []int{0}}}},
+		{Qubits: 1, Gates: []spec.Gate{{Type: "X", Qubits: []int{0}}}},
+	}, Shots: 30})
+	s.Require().NoError(err, "POST /api/execute/batch")
+	s.Require().Len(batch.Results, 3, "POST /api/execute/batch")
+	s.Equal(30, batch.Results[0].Measurements["00"]+batch.Results[0].Measurements["11"], "POST /api/execute/batch")
+	s.Contains(batch.Results[1].Error, "Invalid circuit", "POST /api/execute/batch")
+	s.Equal(0, *batch.Results[1].Gate, "POST /api/execute/batch")
+	s.Equal(map[string]int{"1": 30}, batch.Results[2].Measurements, "POST /api/execute/batch")
+
+	_, err = c.Execute(ctx, &client.CircuitRequest{Circuit: spec.Circuit{Qubits: 1, Gates: []spec.Gate{{Type: "CNOT", Qubits: []int{0}}}}})
+	var apiErr *client.APIError
+	s.Require().ErrorAs(err, &apiErr, "400 POST /api/execute")
+	s.Equal(http.StatusBadRequest, apiErr.StatusCode, "400 POST /api/execute")
+	s.Equal(0, apiErr.Gate, "400 POST /api/execute")
+
+	backends, err := c.Backends(ctx)
+	s.Require().NoError(err, "GET /api/backends")
+	s.Equal("qsim", backends.Default, "GET /api/backends")
+	_, err = c.Backend(ctx, "nope")
+	s.True(client.IsNotFound(err), "404 GET /api/backends/:name")
+
+	job, err := c.SubmitJob(ctx, &client.CircuitRequest{Circuit: bell, Shots: 20})
+	s.Require().NoError(err, "POST /api/jobs")
+	job, err = c.WaitJob(ctx, job.ID, 10*time.Millisecond)
+	s.Require().NoError(err, "GET /api/jobs/:id")
+	s.Equal(client.JobSucceeded, job.State, "GET /api/jobs/:id")
+
+	id, err := c.CreateProgram(ctx, &client.ProgramValue{Name: "bell", Circuit: bell})
+	s.Require().NoError(err, "POST /api/qprogs")
+	prog, err := c.Program(ctx, id)
+	s.Require().NoError(err, "GET /api/qprogs/:id")
+	s.Equal("bell", prog.Name, "GET /api/qprogs/:id")
+	s.Equal(bell.Gates, prog.Circuit.Gates, "GET /api/qprogs/:id")
+	s.NoError(c.DeleteProgram(ctx, id), "DELETE /api/qprogs/:id")
+	_, err = c.Program(ctx, id)
+	s.True(client.IsNotFound(err), "404 GET /api/qprogs/:id")
+}
+
 func TestAppTestSuite(t *testing.T) {
 	suite.Run(t, new(AppServerTestSuite))
 }
+
+func TestServerAuth(t *testing.T) {
+	c := config.NewNakedConfig()
+	c.SetConfigType("yaml")
+	require.NoError(t, c.ReadConfig(strings.NewReader(`
+templatefolder: "testdata/templates"
+quotamaxshots: 100
+apikeys:
+  - key: student-secret
+    name: student
+  - key: teacher-secret
+    name: teacher
+    maxshots: 5000
+    maxqubits: 2
+`)))
+	srv, err := NewServer(ServerOptions{C: c, Version: "test"})
+	require.NoError(t, err)
+	defer srv.Shutdown(context.Background())
+	a := srv.(*appServer)
+
+	execute := func(key string, qubits, shots int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"circuit":{"qubits":%d,"gates":[]},"shots":%d}`, qubits, shots)
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/execute", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, execute("", 1, 10).Code)
+	assert.Equal(t, http.StatusOK, execute("student-secret", 3, 100).Code)
+	assert.Equal(t, http.StatusForbidden, execute("student-secret", 1, 101).Code, "default shot quota")
+	assert.Equal(t, http.StatusOK, execute("teacher-secret", 1, 5000).Code)
+	rec := execute("teacher-secret", 3, 10)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "per-key qubit quota")
+	assert.Contains(t, rec.Body.String(), "at most 2 qubits")
+
+	// A histogram plot without shots runs the default 1000, above the quota
+	rec = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/plots/histogram", strings.NewReader(`{"circuit":{"qubits":1,"gates":[{"type":"H","qubits":[0]}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer student-secret")
+	a.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "plot runs default shots")
+	assert.Contains(t, rec.Body.String(), "at most 100 shots")
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/health", nil)
+	a.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, "health stays public")
+}
+
+func TestAuthOptions_EnvKeys(t *testing.T) {
+	c := config.NewNakedConfig()
+	c.Set("apikeys", " one, ,two ")
+	opts, err := authOptions(c)
+	require.NoError(t, err)
+	if assert.Len(t, opts.Keys, 2) {
+		assert.Equal(t, "one", opts.Keys[0].Key)
+		assert.Equal(t, "two", opts.Keys[1].Key)
+	}
+
+	c.Set("apikeys", []map[string]any{{"name": "nokey"}})
+	_, err = authOptions(c)
+	assert.Error(t, err)
+}
+
+// TestOpenAPI checks that the published document covers every route and
+// that its schemas list the JSON fields of the server and client types.
+func TestOpenAPI(t *testing.T) {
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(openAPIDoc, &doc))
+
+	a := &appServer{}
+	for _, r := range a.routes() {
+		if r.Pattern == "/" {
+			continue // the web UI
+		}
+		path := regexp.MustCompile(`:(\w+)`).ReplaceAllString(r.Pattern, "{$1}")
+		assert.Contains(t, doc.Paths[path], strings.ToLower(r.Method), "route %s %s", r.Method, r.Pattern)
+	}
+
+	for name, types := range map[string][]any{
+		"CircuitRequest":   {CircuitRequest{}, client.CircuitRequest{}},
+		"CircuitResponse":  {CircuitResponse{}, client.CircuitResponse{}},
+		"BatchRequest":     {BatchRequest{}, client.BatchRequest{}},
+		"BatchItem":        {BatchItem{}, client.BatchItem{}},
+		"BatchResponse":    {BatchResponse{}, client.BatchResponse{}},
+		"PlotRequest":      {PlotRequest{}, client.PlotRequest{}},
+		"Circuit":          {spec.Circuit{}},
+		"Gate":             {spec.Gate{}},
+		"Backend":          {BackendResponse{}, client.Backend{}},
+		"BackendList":      {BackendListResponse{}, client.BackendList{}},
+		"BackendInfo":      {simulator.BackendInfo{}},
+		"ExecutionMetrics": {simulator.ExecutionMetrics{}},
+		"CacheStats":       {resultcache.Stats{}, client.CacheStats{}},
+		"Job":              {jobs.Status{}, client.Job{}},
+		"Program":          {qservice.Program{}, client.Program{}},
+		"ProgramValue":     {qservice.ProgramValue{}, client.ProgramValue{}},
+		"ProgramID":        {qservice.ProgramIDValue{}},
+		"ProgramList":      {qservice.ProgramList{}, client.ProgramList{}},
+	} {
+		schema, ok := doc.Components.Schemas[name]
+		if !assert.True(t, ok, "schema %s", name) {
+			continue
+		}
+		var props []string
+		for p := range schema.Properties {
+			props = append(props, p)
+		}
+		for _, v := range types {
+			assert.ElementsMatch(t, props, jsonFields(reflect.TypeOf(v)), "schema %s vs %T", name, v)
+		}
+	}
+}
+
+// jsonFields lists the JSON names of the fields of a struct type, including
+// those of embedded structs.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestServerLimits(t *testing.T) {
+	c := config.NewNakedConfig()
+	c.SetConfigType("yaml")
+	require.NoError(t, c.ReadConfig(strings.NewReader(`
+maxqubits: 3
+maxshots: 50
+defaultshots: 20
+defaultbackend: itsu
+backendmaxqubits:
+  itsu: 2
+`)))
+	srv, err := NewServer(ServerOptions{C: c, Version: "test"})
+	require.NoError(t, err)
+	defer srv.Shutdown(context.Background())
+	a := srv.(*appServer)
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/execute", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		a.router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := execute(`{"circuit":{"qubits":2,"gates":[]},"shots":100}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp CircuitResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "itsu", resp.Backend, "configured default backend")
+	assert.Equal(t, 20, resp.Shots, "out-of-range shots fall back to defaultshots")
+
+	rec = execute(`{"circuit":{"qubits":3,"gates":[]}}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "per-backend qubit cap")
+	assert.Contains(t, rec.Body.String(), "exceeds the limit of 2")
+	assert.Equal(t, http.StatusOK, execute(`{"circuit":{"qubits":3,"gates":[]},"backend":"qsim"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, execute(`{"circuit":{"qubits":4,"gates":[]},"backend":"qsim"}`).Code)
+
+	b, err := a.backends.describe("itsu")
+	require.NoError(t, err)
+	assert.True(t, b.Default)
+	assert.Equal(t, 2, b.MaxQubits)
+
+	c.Set("backendmaxqubits", "nope=1")
+	_, err = NewServer(ServerOptions{C: c, Version: "test"})
+	assert.ErrorContains(t, err, `unknown backend "nope"`)
+}
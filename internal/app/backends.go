@@ -0,0 +1,127 @@
+package app
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+type (
+	// BackendResponse describes one registered backend.
+	BackendResponse struct {
+		Name    string `json:"name"`
+		Default bool   `json:"default"`
+		// MaxQubits is the largest circuit the backend accepts.
+		MaxQubits int `json:"max_qubits"`
+		// Capabilities lists the optional runner interfaces the backend implements.
+		Capabilities   map[string]bool             `json:"capabilities"`
+		Info           *simulator.BackendInfo      `json:"info,omitempty"`
+		SupportedGates []string                    `json:"supported_gates,omitempty"`
+		Metrics        *simulator.ExecutionMetrics `json:"metrics,omitempty"`
+	}
+
+	// BackendListResponse is the body of GET /api/backends.
+	BackendListResponse struct {
+		Default  string            `json:"default"`
+		Backends []BackendResponse `json:"backends"`
+	}
+
+	// backends keeps one runner pool per backend name, so every worker of a
+	// request gets a runner of its own while the metrics the runners
+	// collect add up across requests. Pools are created on first use.
+	backends struct {
+		limits limits
+		mu     sync.Mutex
+		pools  map[string]*simulator.RunnerPool
+	}
+)
+
+func newBackends(lim limits) *backends {
+	return &backends{limits: lim, pools: make(map[string]*simulator.RunnerPool)}
+}
+
+// pool returns the runner pool of a registered backend.
+func (b *backends) pool(name string) (*simulator.RunnerPool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if p, ok := b.pools[name]; ok {
+		return p, nil
+	}
+	factory, err := simulator.GetRunnerFactory(name)
+	if err != nil {
+		return nil, err
+	}
+	p := simulator.NewRunnerPool(factory)
+	b.pools[name] = p
+	return p, nil
+}
+
+// withRunner calls fn with a runner of a backend taken from its pool for
+// the duration of the call.
+func (b *backends) withRunner(name string, fn func(simulator.OneShotRunner) error) error {
+	p, err := b.pool(name)
+	if err != nil {
+		return err
+	}
+	r, err := p.Get()
+	if err != nil {
+		return err
+	}
+	defer p.Put(r)
+	return fn(r)
+}
+
+// each calls fn for every pool created so far, in name order.
+func (b *backends) each(fn func(name string, p *simulator.RunnerPool)) {
+	b.mu.Lock()
+	pools := maps.Clone(b.pools)
+	b.mu.Unlock()
+	for _, name := range slices.Sorted(maps.Keys(pools)) {
+		fn(name, pools[name])
+	}
+}
+
+// names lists the registered backends alphabetically.
+func (b *backends) names() []string {
+	names := simulator.ListRunners()
+	sort.Strings(names)
+	return names
+}
+
+// describe reports the capabilities, metadata and live metrics of a backend.
+func (b *backends) describe(name string) (BackendResponse, error) {
+	p, err := b.pool(name)
+	if err != nil {
+		return BackendResponse{}, err
+	}
+	r, err := p.Get()
+	if err != nil {
+		return BackendResponse{}, err
+	}
+	defer p.Put(r)
+	resp := BackendResponse{
+		Name:      name,
+		Default:   name == b.limits.defaultBackend,
+		MaxQubits: b.limits.qubits(name),
+		Capabilities: map[string]bool{
+			"backend_info":  simulator.SupportsBackendInfo(r),
+			"context":       simulator.SupportsContext(r),
+			"configuration": simulator.SupportsConfiguration(r),
+			"metrics":       simulator.SupportsMetrics(r),
+			"validation":    simulator.SupportsValidation(r),
+			"batch":         simulator.SupportsBatch(r),
+		},
+		Info: simulator.GetBackendInfo(r),
+	}
+	if v, ok := r.(simulator.ValidatingRunner); ok {
+		resp.SupportedGates = v.GetSupportedGates()
+	}
+	if simulator.SupportsMetrics(r) {
+		metrics := p.Metrics()
+		resp.Metrics = &metrics
+	}
+	return resp, nil
+}
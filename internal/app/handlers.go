@@ -2,16 +2,29 @@ package app
 
 import (
 	"bytes"
+	_ "embed"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"image"
 	"image/png"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/internal/jobs"
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/internal/metrics"
+	"github.com/kegliz/qplay/internal/qservice"
+	"github.com/kegliz/qplay/internal/resultcache"
+	"github.com/kegliz/qplay/internal/server/router"
 	"github.com/kegliz/qplay/qc/circuit"
 	"github.com/kegliz/qplay/qc/renderer"
 	"github.com/kegliz/qplay/qc/simulator"
+	"github.com/kegliz/qplay/qc/spec"
 
 	// Import simulators to register them
 	_ "github.com/kegliz/qplay/qc/simulator/itsu"
@@ -20,28 +33,70 @@ import (
 
 // CircuitRequest represents the structure for circuit execution requests
 type CircuitRequest struct {
-	Circuit struct {
-		Qubits int `json:"qubits"`
-		Gates  []struct {
-			Type   string `json:"type"`
-			Qubits []int  `json:"qubits"`
-			Step   int    `json:"step"`
-		} `json:"gates"`
-	} `json:"circuit"`
-	Backend string `json:"backend"`
-	Shots   int    `json:"shots"`
+	Circuit spec.Circuit `json:"circuit"`
+	Backend string       `json:"backend"`
+	Shots   int          `json:"shots"`
+	// Style names the renderer theme of the circuit image (see renderer.Themes).
+	Style string `json:"style"`
+	// Seed tells apart requests that should not share cached results.
+	Seed int64 `json:"seed,omitempty"`
+	// NoCache runs the circuit even when a cached result exists.
+	NoCache bool `json:"no_cache,omitempty"`
 }
 
 // CircuitResponse represents the structure for circuit execution responses
 type CircuitResponse struct {
-	Measurements  map[string]int    `json:"measurements,omitempty"`
-	StateVector   []complex128      `json:"state_vector,omitempty"`
-	CircuitImage  string           `json:"circuit_image,omitempty"`
-	ExecutionTime float64          `json:"execution_time,omitempty"`
-	Backend       string           `json:"backend"`
-	Shots         int              `json:"shots"`
+	Measurements  map[string]int `json:"measurements,omitempty"`
+	StateVector   []complex128   `json:"state_vector,omitempty"`
+	CircuitImage  string         `json:"circuit_image,omitempty"`
+	ExecutionTime float64        `json:"execution_time,omitempty"`
+	Backend       string         `json:"backend"`
+	Shots         int            `json:"shots"`
+	Cached        bool           `json:"cached"`
 }
 
+// PlotRequest represents the structure for plot requests. Histograms plot
+// Counts when given and otherwise execute the circuit; statevector and Bloch
+// plots simulate the circuit with its measurements left out, on a backend
+// that supports state vectors.
+type PlotRequest struct {
+	CircuitRequest
+	Counts map[string]int     `json:"counts"`
+	Ideal  map[string]float64 `json:"ideal"`
+	Format string             `json:"format"` // "png" (default) or "svg"
+}
+
+// BatchRequest represents the structure for batch execution requests: every
+// circuit runs on the same backend for the same number of shots
+type BatchRequest struct {
+	Circuits []spec.Circuit `json:"circuits"`
+	Backend  string         `json:"backend"`
+	Shots    int            `json:"shots"`
+}
+
+// BatchItem is the outcome of one circuit of a batch. Circuits that cannot
+// be built or fail to run carry an error instead of measurements.
+type BatchItem struct {
+	Measurements map[string]int `json:"measurements,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Gate         *int           `json:"gate,omitempty"` // index of the gate an error is about
+}
+
+// BatchResponse represents the structure for batch execution responses; the
+// results are in the order of the circuits
+type BatchResponse struct {
+	Results       []BatchItem `json:"results"`
+	ExecutionTime float64     `json:"execution_time"` // seconds
+	Backend       string      `json:"backend"`
+	Shots         int         `json:"shots"`
+}
+
+// openAPIDoc describes the web API; TestOpenAPI keeps it in sync with the
+// routes and request and response types.
+//
+//go:embed openapi.json
+var openAPIDoc []byte
+
 var badRequestErrorMsg = "Bad Request - please contact the administrator"
 var internalServerErrorMsg = "Internal Server Error - please contact the administrator"
 
@@ -66,6 +121,69 @@ func (a *appServer) HealthHandler(c *gin.Context) {
 	c.String(http.StatusOK, "OK")
 }
 
+// OpenAPI is the handler for the /api/openapi.json endpoint
+func (a *appServer) OpenAPI(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving openapi endpoint")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIDoc)
+}
+
+// ListStyles is the handler for the /api/styles endpoint
+func (a *appServer) ListStyles(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving styles endpoint")
+	c.JSON(http.StatusOK, gin.H{"styles": renderer.Themes()})
+}
+
+// ListBackends is the handler for the /api/backends endpoint
+func (a *appServer) ListBackends(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving backends endpoint")
+
+	resp := BackendListResponse{Default: a.limits.defaultBackend, Backends: []BackendResponse{}}
+	for _, name := range a.backends.names() {
+		b, err := a.backends.describe(name)
+		if err != nil {
+			l.Error().Err(err).Str("backend", name).Msg("describing backend failed")
+			c.String(http.StatusInternalServerError, internalServerErrorMsg)
+			return
+		}
+		resp.Backends = append(resp.Backends, b)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetBackend is the handler for the /api/backends/:name endpoint
+func (a *appServer) GetBackend(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	name := c.Param("name")
+	l.Debug().Str("backend", name).Msg("serving backend endpoint")
+
+	if !slices.Contains(a.backends.names(), name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Unknown backend %q", name)})
+		return
+	}
+	b, err := a.backends.describe(name)
+	if err != nil {
+		l.Error().Err(err).Str("backend", name).Msg("describing backend failed")
+		c.String(http.StatusInternalServerError, internalServerErrorMsg)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
 // ExecuteCircuit is the handler for the /api/execute endpoint
 func (a *appServer) ExecuteCircuit(c *gin.Context) {
 	l, err := a.getLoggerFromContext(c)
@@ -81,31 +199,28 @@ func (a *appServer) ExecuteCircuit(c *gin.Context) {
 		return
 	}
 
-	// Validate request
-	if req.Circuit.Qubits <= 0 || req.Circuit.Qubits > 10 {
-		l.Error().Int("qubits", req.Circuit.Qubits).Msg("invalid qubit count")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid qubit count (1-10 allowed)"})
+	a.limits.applyDefaults(&req)
+	if !a.checkQuota(c, l, &req) {
 		return
 	}
 
-	if req.Shots <= 0 || req.Shots > 10000 {
-		req.Shots = 1000 // Default value
-	}
-
-	if req.Backend == "" {
-		req.Backend = "qsim" // Default backend
+	style, err := renderer.Theme(req.Style)
+	if err != nil {
+		l.Error().Err(err).Str("style", req.Style).Msg("invalid style")
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown style %q (available: %s)", req.Style, strings.Join(renderer.Themes(), ", "))})
+		return
 	}
 
 	// Build circuit from request
-	circ, err := a.buildCircuitFromRequest(&req)
+	circ, err := a.buildCircuit(&req)
 	if err != nil {
 		l.Error().Err(err).Msg("building circuit failed")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to build circuit: " + err.Error()})
+		c.JSON(http.StatusBadRequest, circuitError(err))
 		return
 	}
 
 	// Execute circuit
-	result, err := a.executeCircuit(circ, req.Backend, req.Shots)
+	result, cached, err := a.cachedExecute(circ, &req)
 	if err != nil {
 		l.Error().Err(err).Str("backend", req.Backend).Msg("circuit execution failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Circuit execution failed: " + err.Error()})
@@ -113,7 +228,7 @@ func (a *appServer) ExecuteCircuit(c *gin.Context) {
 	}
 
 	// Generate circuit image
-	circuitImage, err := a.generateCircuitImage(circ)
+	circuitImage, err := a.generateCircuitImage(circ, style)
 	if err != nil {
 		l.Warn().Err(err).Msg("failed to generate circuit image")
 		// Continue without image - not critical
@@ -121,132 +236,513 @@ func (a *appServer) ExecuteCircuit(c *gin.Context) {
 
 	// Prepare response
 	response := CircuitResponse{
-		Measurements:  result,
-		CircuitImage:  circuitImage,
-		Backend:       req.Backend,
-		Shots:         req.Shots,
+		Measurements: result,
+		CircuitImage: circuitImage,
+		Backend:      req.Backend,
+		Shots:        req.Shots,
+		Cached:       cached,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// buildCircuitFromRequest converts the JSON request into a quantum circuit
-func (a *appServer) buildCircuitFromRequest(req *CircuitRequest) (circuit.Circuit, error) {
-	// Create builder with specified qubits and classical bits
-	b := builder.New(builder.Q(req.Circuit.Qubits), builder.C(req.Circuit.Qubits))
+// ExecuteCircuitStream is the handler for the /api/execute/stream endpoint.
+// It runs the circuit like ExecuteCircuit but answers with Server-Sent Events:
+// "progress" events carry histogram snapshots while the shots run, followed by
+// a single "result" or "error" event.
+func (a *appServer) ExecuteCircuitStream(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving circuit execution stream endpoint")
 
-	// Sort gates by step to ensure proper order
-	gatesByStep := make(map[int][]struct {
-		Type   string `json:"type"`
-		Qubits []int  `json:"qubits"`
-		Step   int    `json:"step"`
-	})
+	var req CircuitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Error().Err(err).Msg("binding JSON failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	a.limits.applyDefaults(&req)
+	if !a.checkQuota(c, l, &req) {
+		return
+	}
+	circ, err := a.buildCircuit(&req)
+	if err != nil {
+		l.Error().Err(err).Msg("building circuit failed")
+		c.JSON(http.StatusBadRequest, circuitError(err))
+		return
+	}
+	pool, err := a.backends.pool(req.Backend)
+	if err != nil {
+		l.Error().Err(err).Str("backend", req.Backend).Msg("creating runner failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	for _, gate := range req.Circuit.Gates {
-		gatesByStep[gate.Step] = append(gatesByStep[gate.Step], gate)
-	}
-
-	// Add gates in order
-	for step := 0; step < 10; step++ {
-		gates := gatesByStep[step]
-		for _, gate := range gates {
-			switch gate.Type {
-			case "H":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("H gate requires exactly 1 qubit")
-				}
-				b.H(gate.Qubits[0])
-			case "X":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("X gate requires exactly 1 qubit")
-				}
-				b.X(gate.Qubits[0])
-			case "Y":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("Y gate requires exactly 1 qubit")
-				}
-				b.Y(gate.Qubits[0])
-			case "Z":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("Z gate requires exactly 1 qubit")
-				}
-				b.Z(gate.Qubits[0])
-			case "S":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("S gate requires exactly 1 qubit")
-				}
-				b.S(gate.Qubits[0])
-			case "CNOT":
-				if len(gate.Qubits) != 2 {
-					return nil, fmt.Errorf("CNOT gate requires exactly 2 qubits")
-				}
-				b.CNOT(gate.Qubits[0], gate.Qubits[1])
-			case "CZ":
-				if len(gate.Qubits) != 2 {
-					return nil, fmt.Errorf("CZ gate requires exactly 2 qubits")
-				}
-				b.CZ(gate.Qubits[0], gate.Qubits[1])
-			case "SWAP":
-				if len(gate.Qubits) != 2 {
-					return nil, fmt.Errorf("SWAP gate requires exactly 2 qubits")
-				}
-				b.SWAP(gate.Qubits[0], gate.Qubits[1])
-			case "MEASURE":
-				if len(gate.Qubits) != 1 {
-					return nil, fmt.Errorf("MEASURE requires exactly 1 qubit")
-				}
-				b.Measure(gate.Qubits[0], gate.Qubits[0])
+	// Snapshots are dropped rather than stalling the workers when the client
+	// reads slowly; the result event always carries the full histogram.
+	progress := make(chan simulator.Progress, 16)
+	done := make(chan streamOutcome, 1)
+	sim := simulator.NewSimulator(simulator.SimulatorOptions{
+		Shots:   req.Shots,
+		Pool:    pool,
+		Backend: req.Backend,
+		Progress: func(p simulator.Progress) {
+			select {
+			case progress <- p:
 			default:
-				return nil, fmt.Errorf("unsupported gate type: %s", gate.Type)
 			}
+		},
+	})
+	// the workers stop as soon as the client goes away
+	go func() {
+		hist, err := sim.RunContext(c.Request.Context(), circ)
+		done <- streamOutcome{hist, err}
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			l.Debug().Msg("stream client went away")
+			return
+		case p := <-progress:
+			c.SSEvent("progress", gin.H{"completed": p.Completed, "total": p.Total, "histogram": p.Histogram})
+		case out := <-done:
+			if out.err != nil {
+				a.metrics.simulation(req.Backend, "error", 0)
+				l.Error().Err(out.err).Str("backend", req.Backend).Msg("circuit execution failed")
+				c.SSEvent("error", gin.H{"error": "Circuit execution failed: " + out.err.Error()})
+			} else {
+				a.metrics.simulation(req.Backend, "ok", req.Shots)
+				c.SSEvent("result", CircuitResponse{Measurements: out.hist, Backend: req.Backend, Shots: req.Shots})
+			}
+			c.Writer.Flush()
+			return
 		}
+		c.Writer.Flush()
 	}
+}
 
-	// Automatically add measurements if none specified
-	hasMeasurements := false
-	for _, gate := range req.Circuit.Gates {
-		if gate.Type == "MEASURE" {
-			hasMeasurements = true
-			break
+// ExecuteBatch is the handler for the /api/execute/batch endpoint. All shots
+// of all circuits are scheduled on one simulator, so many small circuits run
+// about as fast as one large one; errors are reported per circuit.
+func (a *appServer) ExecuteBatch(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving batch execution endpoint")
+
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Error().Err(err).Msg("binding JSON failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if len(req.Circuits) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No circuits given"})
+		return
+	}
+	reqs := make([]CircuitRequest, len(req.Circuits))
+	for i, sc := range req.Circuits {
+		reqs[i] = CircuitRequest{Circuit: sc, Backend: req.Backend, Shots: req.Shots}
+		a.limits.applyDefaults(&reqs[i])
+		if !a.checkQuota(c, l, &reqs[i]) {
+			return
+		}
+	}
+	req.Backend, req.Shots = reqs[0].Backend, reqs[0].Shots
+	if total := len(reqs) * req.Shots; total > a.limits.maxJobShots {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many shots in batch: %d (at most %d allowed)", total, a.limits.maxJobShots)})
+		return
+	}
+	pool, err := a.backends.pool(req.Backend)
+	if err != nil {
+		l.Error().Err(err).Str("backend", req.Backend).Msg("creating runner failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := BatchResponse{Results: make([]BatchItem, len(reqs)), Backend: req.Backend, Shots: req.Shots}
+	circs := make([]circuit.Circuit, len(reqs))
+	for i := range reqs {
+		if circs[i], err = a.buildCircuit(&reqs[i]); err != nil {
+			response.Results[i].Error = "Invalid circuit: " + err.Error()
+			var ge *spec.GateError
+			if errors.As(err, &ge) {
+				response.Results[i].Gate = &ge.Index
+			}
+		}
+	}
+
+	start := time.Now()
+	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: req.Shots, Pool: pool, Backend: req.Backend})
+	results := sim.RunMany(c.Request.Context(), circs)
+	response.ExecutionTime = time.Since(start).Seconds()
+	for i, res := range results {
+		switch {
+		case circs[i] == nil:
+		case res.Err != nil:
+			a.metrics.simulation(req.Backend, "error", 0)
+			response.Results[i].Error = "Circuit execution failed: " + res.Err.Error()
+		default:
+			a.metrics.simulation(req.Backend, "ok", req.Shots)
+			response.Results[i].Measurements = res.Counts
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// streamOutcome is the final outcome of a streamed execution.
+type streamOutcome struct {
+	hist map[string]int
+	err  error
+}
+
+// PlotCircuit is the handler for the /api/plots/:kind endpoint, where kind is
+// histogram, statevector or bloch
+func (a *appServer) PlotCircuit(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	kind := c.Param("kind")
+	l.Debug().Str("kind", kind).Msg("serving plot endpoint")
+
+	var req PlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Error().Err(err).Msg("binding JSON failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "png"
+	}
+	if req.Format != "png" && req.Format != "svg" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (png or svg allowed)"})
+		return
+	}
+	style, err := renderer.Theme(req.Style)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown style %q (available: %s)", req.Style, strings.Join(renderer.Themes(), ", "))})
+		return
+	}
+	if kind == "histogram" && len(req.Counts) == 0 {
+		// the circuit is executed: quota applies to the shots actually run
+		a.limits.applyDefaults(&req.CircuitRequest)
+	}
+	if !a.checkQuota(c, l, &req.CircuitRequest) {
+		return
+	}
+
+	var toPNG func() (image.Image, error)
+	var toSVG func() (string, error)
+	switch kind {
+	case "histogram":
+		counts := req.Counts
+		if len(counts) == 0 {
+			if counts, err = a.plotCounts(&req); err != nil {
+				l.Error().Err(err).Msg("executing circuit for histogram failed")
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		p := renderer.NewHistogramPlot(640, 400)
+		p.Style, p.Ideal = style, req.Ideal
+		toPNG = func() (image.Image, error) { return p.Render(counts) }
+		toSVG = func() (string, error) { return p.SVG(counts) }
+	case "statevector", "bloch":
+		amps, err := a.plotStateVector(&req)
+		if err != nil {
+			l.Error().Err(err).Msg("computing statevector failed")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if kind == "statevector" {
+			p := renderer.NewStatevectorPlot(640, 400)
+			p.Style = style
+			toPNG = func() (image.Image, error) { return p.Render(amps) }
+			toSVG = func() (string, error) { return p.SVG(amps) }
+		} else {
+			p := renderer.NewBlochPlot(240)
+			p.Style = style
+			toPNG = func() (image.Image, error) { return p.Render(amps) }
+			toSVG = func() (string, error) { return p.SVG(amps) }
 		}
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Unknown plot %q (histogram, statevector or bloch)", kind)})
+		return
+	}
+
+	if req.Format == "svg" {
+		doc, err := toSVG()
+		if err != nil {
+			l.Error().Err(err).Msg("plotting failed")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Plotting failed: " + err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "image/svg+xml", []byte(doc))
+		return
 	}
+	img, err := toPNG()
+	if err != nil {
+		l.Error().Err(err).Msg("plotting failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plotting failed: " + err.Error()})
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		l.Error().Err(err).Msg("encoding PNG failed")
+		c.String(http.StatusInternalServerError, internalServerErrorMsg)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", buf.Bytes())
+}
+
+// plotCounts executes the circuit of a plot request
+func (a *appServer) plotCounts(req *PlotRequest) (map[string]int, error) {
+	circ, err := a.buildCircuit(&req.CircuitRequest)
+	if err != nil {
+		return nil, fmt.Errorf("either counts or a valid circuit is required: %w", err)
+	}
+	hist, _, err := a.cachedExecute(circ, &req.CircuitRequest)
+	return hist, err
+}
+
+// stateVectorRunner is a runner that can return the final state of a circuit
+type stateVectorRunner interface {
+	StateVector(circuit.Circuit) ([]complex128, error)
+}
 
-	if !hasMeasurements {
-		for i := 0; i < req.Circuit.Qubits; i++ {
-			b.Measure(i, i)
+// plotStateVector simulates the circuit of a plot request without
+// measurements on the requested backend, which must support state vectors
+func (a *appServer) plotStateVector(req *PlotRequest) ([]complex128, error) {
+	if req.Backend == "" {
+		req.Backend = a.limits.defaultBackend
+	}
+	circ, err := a.buildCircuit(&req.CircuitRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build circuit: %w", err)
+	}
+	var amps []complex128
+	err = a.backends.withRunner(req.Backend, func(r simulator.OneShotRunner) error {
+		sv, ok := r.(stateVectorRunner)
+		if !ok {
+			return fmt.Errorf("backend %s does not support state vectors", req.Backend)
 		}
+		if v, ok := r.(simulator.ValidatingRunner); ok {
+			if err := v.ValidateCircuit(circ); err != nil {
+				return fmt.Errorf("backend %s cannot run the circuit: %w", req.Backend, err)
+			}
+		}
+		amps, err = sv.StateVector(circ)
+		return err
+	})
+	return amps, err
+}
+
+// SubmitJob is the handler for the POST /api/jobs endpoint
+func (a *appServer) SubmitJob(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving job submission endpoint")
+
+	var req CircuitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Error().Err(err).Msg("binding JSON failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if req.Shots <= 0 {
+		req.Shots = a.limits.defaultShots
+	}
+	if req.Shots > a.limits.maxJobShots {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many shots (at most %d allowed)", a.limits.maxJobShots)})
+		return
+	}
+	if req.Backend == "" {
+		req.Backend = a.limits.defaultBackend
+	}
+	if !a.checkQuota(c, l, &req) {
+		return
+	}
+	circ, err := a.buildCircuit(&req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, circuitError(err))
+		return
 	}
 
-	return b.BuildCircuit()
+	status, err := a.jobs.Submit(jobs.Request{Circuit: circ, Backend: req.Backend, Shots: req.Shots})
+	switch {
+	case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrClosed):
+		l.Warn().Err(err).Msg("job rejected")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Job queue is full, try again later"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, status)
+}
+
+// ListJobs is the handler for the GET /api/jobs endpoint
+func (a *appServer) ListJobs(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving job list endpoint")
+	c.JSON(http.StatusOK, gin.H{"jobs": a.jobs.List()})
+}
+
+// GetJob is the handler for the GET /api/jobs/:id endpoint
+func (a *appServer) GetJob(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving job status endpoint")
+	status, err := a.jobs.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// CancelJob is the handler for the DELETE /api/jobs/:id endpoint
+func (a *appServer) CancelJob(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving job cancellation endpoint")
+	status, err := a.jobs.Cancel(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
 }
 
 // executeCircuit runs the circuit on the specified backend
 func (a *appServer) executeCircuit(circ circuit.Circuit, backend string, shots int) (map[string]int, error) {
-	// Create runner for the specified backend
-	runner, err := simulator.CreateRunner(backend)
+	// Runner pool of the specified backend
+	pool, err := a.backends.pool(backend)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create %s runner: %w", backend, err)
 	}
 
 	// Create simulator
 	sim := simulator.NewSimulator(simulator.SimulatorOptions{
-		Shots:  shots,
-		Runner: runner,
+		Shots:   shots,
+		Pool:    pool,
+		Backend: backend,
 	})
 
 	// Run simulation
 	results, err := sim.RunSerial(circ)
 	if err != nil {
+		a.metrics.simulation(backend, "error", 0)
 		return nil, fmt.Errorf("simulation failed: %w", err)
 	}
+	a.metrics.simulation(backend, "ok", shots)
 
 	return results, nil
 }
 
-// generateCircuitImage creates a PNG image of the circuit
-func (a *appServer) generateCircuitImage(circ circuit.Circuit) (string, error) {
+// cachedExecute answers a request from the result cache when possible and
+// executes the circuit otherwise; the boolean reports a cache hit. NoCache
+// requests always execute but still refresh the cache.
+func (a *appServer) cachedExecute(circ circuit.Circuit, req *CircuitRequest) (map[string]int, bool, error) {
+	key := resultcache.Key{Hash: circuit.Hash(circ), Backend: req.Backend, Shots: req.Shots, Seed: req.Seed}
+	if !req.NoCache {
+		if hist, ok := a.cache.Get(key); ok {
+			return hist, true, nil
+		}
+	}
+	hist, err := a.executeCircuit(circ, req.Backend, req.Shots)
+	if err != nil {
+		return nil, false, err
+	}
+	a.cache.Put(key, hist)
+	return hist, false, nil
+}
+
+// MetricsHandler is the handler for the /metrics endpoint, serving the
+// Prometheus text exposition format
+func (a *appServer) MetricsHandler(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving metrics endpoint")
+
+	var buf bytes.Buffer
+	if err := a.registry.Write(&buf); err != nil {
+		l.Error().Err(err).Msg("writing metrics failed")
+		c.String(http.StatusInternalServerError, internalServerErrorMsg)
+		return
+	}
+	c.Data(http.StatusOK, metrics.ContentType, buf.Bytes())
+}
+
+// CacheStats is the handler for the /api/cache endpoint
+func (a *appServer) CacheStats(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving cache stats endpoint")
+	c.JSON(http.StatusOK, a.cache.Stats())
+}
+
+// checkQuota answers 403 and returns false when the request asks for more
+// qubits or shots than the quota of its client allows
+func (a *appServer) checkQuota(c *gin.Context, l *logger.Logger, req *CircuitRequest) bool {
+	q := router.QuotaFrom(c)
+	var msg string
+	switch {
+	case q.MaxQubits > 0 && req.Circuit.Qubits > q.MaxQubits:
+		msg = fmt.Sprintf("Quota exceeded: at most %d qubits allowed", q.MaxQubits)
+	case q.MaxShots > 0 && req.Shots > q.MaxShots:
+		msg = fmt.Sprintf("Quota exceeded: at most %d shots allowed", q.MaxShots)
+	default:
+		return true
+	}
+	l.Warn().Str("apikey", router.APIKeyName(c)).Int("qubits", req.Circuit.Qubits).Int("shots", req.Shots).Msg("quota exceeded")
+	c.JSON(http.StatusForbidden, gin.H{"error": msg})
+	return false
+}
+
+// buildCircuit builds the circuit of a request within the qubit limit of
+// its backend
+func (a *appServer) buildCircuit(req *CircuitRequest) (circuit.Circuit, error) {
+	return qservice.BuildCircuit(&req.Circuit, a.limits.qubits(req.Backend))
+}
+
+// circuitError is the response body of a circuit that cannot be built. Errors
+// caused by a single gate also carry its index.
+func circuitError(err error) gin.H {
+	body := gin.H{"error": "Invalid circuit: " + err.Error()}
+	var ge *spec.GateError
+	if errors.As(err, &ge) {
+		body["gate"] = ge.Index
+	}
+	return body
+}
+
+// generateCircuitImage creates a PNG image of the circuit in the given style
+func (a *appServer) generateCircuitImage(circ circuit.Circuit, style renderer.Style) (string, error) {
 	// Create renderer
-	r := renderer.NewRenderer(60) // 60 DPI for web display
+	r := renderer.NewRenderer(a.limits.cellSize, renderer.WithStyle(style))
 
 	// Render circuit to image
 	img, err := r.Render(circ)
@@ -268,27 +764,98 @@ func (a *appServer) generateCircuitImage(circ circuit.Circuit) (string, error) {
 	return encoded, nil
 }
 
-// CreateCircuit is the handler for the /api/qprogs endpoint
+// CreateCircuit is the handler for the POST /api/qprogs endpoint
 func (a *appServer) CreateCircuit(c *gin.Context) {
 	l, err := a.getLoggerFromContext(c)
 	if err != nil {
 		panic("logger not found in context")
 	}
 	l.Debug().Msg("serving qprog creation endpoint")
-	// var params qservice.ProgramValue
-	// if err := c.ShouldBindJSON(&params); err != nil {
-	// 	l.Error().Err(err).Msg("binding json failed")
-	// 	c.String(http.StatusBadRequest, badRequestErrorMsg)
-	// 	return
-	// }
-	// // Save the circuit
-	// id, err := a.qs.SaveProgram(l, &params)
-	// if err != nil {
-	// 	l.Error().Err(err).Msg("saving circuit failed")
-	// 	c.String(http.StatusInternalServerError, internalServerErrorMsg)
-	// 	return
-	// }
-	// c.PureJSON(http.StatusOK, qservice.ProgramIDValue{ID: id})
+	var params qservice.ProgramValue
+	if err := c.ShouldBindJSON(&params); err != nil {
+		l.Error().Err(err).Msg("binding json failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	// Save the circuit
+	id, err := a.qs.SaveProgram(l, &params)
+	if err != nil {
+		a.qprogError(c, l, err, "saving circuit failed")
+		return
+	}
+	c.PureJSON(http.StatusOK, qservice.ProgramIDValue{ID: id})
+}
+
+// ListCircuits is the handler for the GET /api/qprogs endpoint; the offset
+// and limit query parameters select a page
+func (a *appServer) ListCircuits(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving qprog list endpoint")
+	offset, err1 := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, err2 := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset and limit must be integers"})
+		return
+	}
+	list, err := a.qs.ListPrograms(l, offset, limit)
+	if err != nil {
+		a.qprogError(c, l, err, "listing circuits failed")
+		return
+	}
+	c.PureJSON(http.StatusOK, list)
+}
+
+// GetCircuit is the handler for the GET /api/qprogs/:id endpoint
+func (a *appServer) GetCircuit(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving qprog get endpoint")
+	prog, err := a.qs.GetProgram(l, c.Param("id"))
+	if err != nil {
+		a.qprogError(c, l, err, "getting circuit failed")
+		return
+	}
+	c.PureJSON(http.StatusOK, prog)
+}
+
+// UpdateCircuit is the handler for the PUT /api/qprogs/:id endpoint
+func (a *appServer) UpdateCircuit(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving qprog update endpoint")
+	var params qservice.ProgramValue
+	if err := c.ShouldBindJSON(&params); err != nil {
+		l.Error().Err(err).Msg("binding json failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	prog, err := a.qs.UpdateProgram(l, c.Param("id"), &params)
+	if err != nil {
+		a.qprogError(c, l, err, "updating circuit failed")
+		return
+	}
+	c.PureJSON(http.StatusOK, prog)
+}
+
+// DeleteCircuit is the handler for the DELETE /api/qprogs/:id endpoint
+func (a *appServer) DeleteCircuit(c *gin.Context) {
+	l, err := a.getLoggerFromContext(c)
+	if err != nil {
+		panic("logger not found in context")
+	}
+	l.Debug().Msg("serving qprog delete endpoint")
+	if err := a.qs.DeleteProgram(l, c.Param("id")); err != nil {
+		a.qprogError(c, l, err, "deleting circuit failed")
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
 
 // RenderCircuit is the handler for the /api/qprogs/:id/img endpoint
@@ -298,14 +865,34 @@ func (a *appServer) RenderCircuit(c *gin.Context) {
 		panic("logger not found in context")
 	}
 	l.Debug().Msg("serving rendering circuit img endpoint")
-	// id := c.Param("id")
-	// img, err := a.qs.RenderCircuit(l, id)
-	// if err != nil {
-	// 	l.Error().Err(err).Msg("rendering circuit failed")
-	// 	c.String(http.StatusInternalServerError, internalServerErrorMsg)
-	// 	return
-	// }
-	// c.Header("Content-Type", "image/png")
-	// png.Encode(c.Writer, img)
-	// c.Status(http.StatusOK)
+	id := c.Param("id")
+	img, err := a.qs.RenderCircuit(l, id)
+	if err != nil {
+		a.qprogError(c, l, err, "rendering circuit failed")
+		return
+	}
+	c.Header("Content-Type", "image/png")
+	c.Status(http.StatusOK)
+	if err := png.Encode(c.Writer, img); err != nil {
+		l.Error().Err(err).Msg("encoding PNG failed")
+	}
+}
+
+// qprogError answers a failed program service call: unknown programs are
+// 404, invalid ones 400 and anything else 500
+func (a *appServer) qprogError(c *gin.Context, l *logger.Logger, err error, msg string) {
+	switch {
+	case errors.Is(err, qservice.ErrProgramNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+	case errors.Is(err, qservice.ErrInvalidProgram):
+		body := gin.H{"error": err.Error()}
+		var ge *spec.GateError
+		if errors.As(err, &ge) {
+			body["gate"] = ge.Index
+		}
+		c.JSON(http.StatusBadRequest, body)
+	default:
+		l.Error().Err(err).Msg(msg)
+		c.String(http.StatusInternalServerError, internalServerErrorMsg)
+	}
 }
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kegliz/qplay/internal/config"
+	"github.com/kegliz/qplay/internal/qservice"
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+// limits are the service limits and defaults of the web API. Unset config
+// vars fall back to the defaults below, so a naked config works too.
+type limits struct {
+	maxQubits      int
+	maxShots       int
+	defaultShots   int
+	maxJobShots    int
+	defaultBackend string
+	// backendQubits caps the qubits of single backends below maxQubits.
+	backendQubits map[string]int
+	cellSize      int
+}
+
+// newLimits reads the limits from the config and checks that the backends
+// it names are registered.
+func newLimits(c *config.Config) (limits, error) {
+	l := limits{
+		maxQubits:      orDefault(c.GetInt("maxqubits"), qservice.DefaultMaxQubits),
+		maxShots:       orDefault(c.GetInt("maxshots"), 10000),
+		defaultShots:   orDefault(c.GetInt("defaultshots"), 1000),
+		maxJobShots:    orDefault(c.GetInt("maxjobshots"), 1_000_000),
+		defaultBackend: c.GetString("defaultbackend"),
+		cellSize:       orDefault(c.GetInt("rendercellsize"), 60),
+	}
+	if l.defaultBackend == "" {
+		l.defaultBackend = "qsim"
+	}
+	registered := simulator.ListRunners()
+	if !slices.Contains(registered, l.defaultBackend) {
+		return l, fmt.Errorf("defaultbackend: unknown backend %q (available: %v)", l.defaultBackend, registered)
+	}
+	var err error
+	if l.backendQubits, err = c.GetIntMap("backendmaxqubits"); err != nil {
+		return l, fmt.Errorf("backendmaxqubits: %w", err)
+	}
+	for name := range l.backendQubits {
+		if !slices.Contains(registered, name) {
+			return l, fmt.Errorf("backendmaxqubits: unknown backend %q (available: %v)", name, registered)
+		}
+	}
+	return l, nil
+}
+
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// qubits returns the largest circuit a backend accepts.
+func (l limits) qubits(backend string) int {
+	if n := l.backendQubits[backend]; n > 0 && n < l.maxQubits {
+		return n
+	}
+	return l.maxQubits
+}
+
+// applyDefaults fills in the backend and, for interactive requests, replaces
+// out-of-range shots with the default.
+func (l limits) applyDefaults(req *CircuitRequest) {
+	if req.Shots <= 0 || req.Shots > l.maxShots {
+		req.Shots = l.defaultShots
+	}
+	if req.Backend == "" {
+		req.Backend = l.defaultBackend
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"github.com/kegliz/qplay/internal/jobs"
+	"github.com/kegliz/qplay/internal/metrics"
+	"github.com/kegliz/qplay/internal/resultcache"
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+// appMetrics are the service metrics exported on /metrics next to the
+// request metrics of the router.
+type appMetrics struct {
+	simulations *metrics.CounterVec
+	shots       *metrics.CounterVec
+}
+
+// newAppMetrics registers the simulation counters and the gauges and
+// counters read from the job manager, the result cache and the runners.
+func newAppMetrics(reg *metrics.Registry, bs *backends, rc *resultcache.Cache, jm *jobs.Manager) *appMetrics {
+	m := &appMetrics{
+		simulations: reg.Counter("qplay_simulations_total", "Circuit executions, by backend and outcome (ok or error).", "backend", "outcome"),
+		shots:       reg.Counter("qplay_shots_total", "Shots simulated, by backend.", "backend"),
+	}
+	reg.GaugeFunc("qplay_job_queue_depth", "Jobs waiting for a worker.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(jm.QueueDepth())}}
+	})
+	reg.CounterFunc("qplay_result_cache_requests_total", "Result cache lookups, by result (hit or miss).", []string{"result"}, func() []metrics.Sample {
+		s := rc.Stats()
+		return []metrics.Sample{{Labels: []string{"hit"}, Value: float64(s.Hits)}, {Labels: []string{"miss"}, Value: float64(s.Misses)}}
+	})
+	reg.CounterFunc("qplay_runner_runs_total", "Single-shot runs reported by runners that collect metrics, by backend and outcome.", []string{"backend", "outcome"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		bs.each(func(name string, p *simulator.RunnerPool) {
+			var collects bool
+			bs.withRunner(name, func(r simulator.OneShotRunner) error {
+				collects = simulator.SupportsMetrics(r)
+				return nil
+			})
+			if collects {
+				em := p.Metrics()
+				samples = append(samples,
+					metrics.Sample{Labels: []string{name, "success"}, Value: float64(em.SuccessfulRuns)},
+					metrics.Sample{Labels: []string{name, "failure"}, Value: float64(em.FailedRuns)})
+			}
+		})
+		return samples
+	})
+	return m
+}
+
+// simulation records one execution that ran shots shots; outcome is "ok"
+// or "error".
+func (m *appMetrics) simulation(backend, outcome string, shots int) {
+	m.simulations.With(backend, outcome).Inc()
+	m.shots.With(backend).Add(float64(shots))
+}
+
+// job records a finished background job. Cancelled jobs only count their
+// shots.
+func (m *appMetrics) job(s jobs.Status) {
+	switch s.State {
+	case jobs.Succeeded:
+		m.simulation(s.Backend, "ok", s.Completed)
+	case jobs.Failed:
+		m.simulation(s.Backend, "error", s.Completed)
+	default:
+		m.shots.With(s.Backend).Add(float64(s.Completed))
+	}
+}
@@ -20,18 +20,120 @@ func (a *appServer) routes() []*router.Route {
 			Pattern:     "/health",
 			HandlerFunc: a.HealthHandler,
 		},
+		{
+			Name:        "metrics",
+			Method:      http.MethodGet,
+			Pattern:     "/metrics",
+			HandlerFunc: a.MetricsHandler,
+		},
+		{
+			Name:        "api.openapi",
+			Method:      http.MethodGet,
+			Pattern:     "/api/openapi.json",
+			HandlerFunc: a.OpenAPI,
+		},
 		{
 			Name:        "api.execute",
 			Method:      http.MethodPost,
 			Pattern:     "/api/execute",
 			HandlerFunc: a.ExecuteCircuit,
 		},
+		{
+			Name:        "api.execute.stream",
+			Method:      http.MethodPost,
+			Pattern:     "/api/execute/stream",
+			HandlerFunc: a.ExecuteCircuitStream,
+		},
+		{
+			Name:        "api.execute.batch",
+			Method:      http.MethodPost,
+			Pattern:     "/api/execute/batch",
+			HandlerFunc: a.ExecuteBatch,
+		},
+		{
+			Name:        "api.styles",
+			Method:      http.MethodGet,
+			Pattern:     "/api/styles",
+			HandlerFunc: a.ListStyles,
+		},
+		{
+			Name:        "api.backends.list",
+			Method:      http.MethodGet,
+			Pattern:     "/api/backends",
+			HandlerFunc: a.ListBackends,
+		},
+		{
+			Name:        "api.backends.get",
+			Method:      http.MethodGet,
+			Pattern:     "/api/backends/:name",
+			HandlerFunc: a.GetBackend,
+		},
+		{
+			Name:        "api.cache",
+			Method:      http.MethodGet,
+			Pattern:     "/api/cache",
+			HandlerFunc: a.CacheStats,
+		},
+		{
+			Name:        "api.plots",
+			Method:      http.MethodPost,
+			Pattern:     "/api/plots/:kind",
+			HandlerFunc: a.PlotCircuit,
+		},
+		{
+			Name:        "api.jobs.submit",
+			Method:      http.MethodPost,
+			Pattern:     "/api/jobs",
+			HandlerFunc: a.SubmitJob,
+		},
+		{
+			Name:        "api.jobs.list",
+			Method:      http.MethodGet,
+			Pattern:     "/api/jobs",
+			HandlerFunc: a.ListJobs,
+		},
+		{
+			Name:        "api.jobs.get",
+			Method:      http.MethodGet,
+			Pattern:     "/api/jobs/:id",
+			HandlerFunc: a.GetJob,
+		},
+		{
+			Name:        "api.jobs.cancel",
+			Method:      http.MethodDelete,
+			Pattern:     "/api/jobs/:id",
+			HandlerFunc: a.CancelJob,
+		},
 		{
 			Name:        "api.qprogs.save",
 			Method:      http.MethodPost,
 			Pattern:     "/api/qprogs",
 			HandlerFunc: a.CreateCircuit,
 		},
+		{
+			Name:        "api.qprogs.list",
+			Method:      http.MethodGet,
+			Pattern:     "/api/qprogs",
+			HandlerFunc: a.ListCircuits,
+		},
+		{
+			Name:        "api.qprogs.get",
+			Method:      http.MethodGet,
+			Pattern:     "/api/qprogs/:id",
+			HandlerFunc: a.GetCircuit,
+		},
+		{
+			Name:        "api.qprogs.update",
+			Method:      http.MethodPut,
+			Pattern:     "/api/qprogs/:id",
+			HandlerFunc: a.UpdateCircuit,
+		},
+		{
+			Name:        "api.qprogs.delete",
+			Method:      http.MethodDelete,
+			Pattern:     "/api/qprogs/:id",
+			HandlerFunc: a.DeleteCircuit,
+		},
 		{
 			Name:        "api.qprogs.render",
 			Method:      http.MethodGet,
@@ -60,3 +60,46 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(standuppers[0], "TestBela")
 	os.Setenv("BELA_BACSI", "susu")
 }
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	newConf := func() *Config {
+		conf := NewNakedConfig()
+		for key, configVar := range configVars {
+			conf.SetDefault(key, configVar.Default)
+		}
+		return conf
+	}
+	assert.NoError(newConf().Validate(), "defaults are valid")
+
+	conf := newConf()
+	conf.Set("port", "http")
+	conf.Set("debug", "maybe")
+	err := conf.Validate()
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "config debug (DEBUG): ")
+		assert.Contains(err.Error(), "config port (PORT): ")
+	}
+
+	conf = newConf()
+	conf.Set("maxshots", 500)
+	conf.Set("maxqubits", 0)
+	conf.Set("backendmaxqubits", "itsu=8, qsim=12")
+	err = conf.Validate()
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "config maxqubits (MAXQUBITS): must be positive, got 0")
+		assert.Contains(err.Error(), "config defaultshots (DEFAULTSHOTS): 1000 exceeds maxshots (500)")
+		assert.Contains(err.Error(), "itsu: 8 is not between 1 and maxqubits (0)")
+	}
+
+	conf = newConf()
+	conf.Set("backendmaxqubits", map[string]any{"itsu": 8, "qsim": "9"})
+	assert.NoError(conf.Validate())
+	caps, err := conf.GetIntMap("backendmaxqubits")
+	assert.NoError(err)
+	assert.Equal(map[string]int{"itsu": 8, "qsim": 9}, caps)
+
+	conf.Set("backendmaxqubits", "itsu")
+	assert.ErrorContains(conf.Validate(), `"itsu" is not a name=value pair`)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Validate checks that every config var has a value of its type and that
+// the service limits are consistent. All problems are reported at once,
+// one per line, naming the offending key and its environment variable.
+func (conf *Config) Validate() error {
+	keys := make([]string, 0, len(configVars))
+	for key := range configVars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		if env := configVars[key].EnvVar; env != "" {
+			errs = append(errs, fmt.Errorf("config %s (%s): %s", key, env, msg))
+		} else {
+			errs = append(errs, fmt.Errorf("config %s: %s", key, msg))
+		}
+	}
+
+	for _, key := range keys {
+		v := conf.Get(key)
+		var err error
+		switch configVars[key].Type {
+		case intType:
+			_, err = cast.ToIntE(v)
+		case boolType:
+			_, err = cast.ToBoolE(v)
+		case stringType:
+			_, err = cast.ToStringE(v)
+		case mapType:
+			_, err = conf.GetIntMap(key)
+		case listType:
+			switch v.(type) {
+			case nil, string, []any, []map[string]any:
+			default:
+				err = fmt.Errorf("%v is neither a string nor a list", v)
+			}
+		}
+		if err != nil {
+			fail(key, "%v", err)
+		}
+	}
+	if len(errs) > 0 {
+		// the checks below would only repeat the type errors
+		return errors.Join(errs...)
+	}
+
+	positive := func(key string) {
+		if n := conf.GetInt(key); n <= 0 {
+			fail(key, "must be positive, got %d", n)
+		}
+	}
+	notNegative := func(key string) {
+		if n := conf.GetInt(key); n < 0 {
+			fail(key, "must not be negative, got %d", n)
+		}
+	}
+	if p := conf.GetInt("port"); p < 1 || p > 65535 {
+		fail("port", "must be between 1 and 65535, got %d", p)
+	}
+	for _, key := range []string{"maxqubits", "maxshots", "defaultshots", "maxjobshots", "rendercellsize", "jobworkers", "jobqueuesize"} {
+		positive(key)
+	}
+	for _, key := range []string{"gracefulshutdowntimeout", "jobretention", "resultcachesize", "resultcachettl", "ratelimit", "rateburst", "quotamaxshots", "quotamaxqubits"} {
+		notNegative(key)
+	}
+	if d, m := conf.GetInt("defaultshots"), conf.GetInt("maxshots"); d > m {
+		fail("defaultshots", "%d exceeds maxshots (%d)", d, m)
+	}
+	if m, j := conf.GetInt("maxshots"), conf.GetInt("maxjobshots"); m > j {
+		fail("maxshots", "%d exceeds maxjobshots (%d)", m, j)
+	}
+	if conf.GetString("defaultbackend") == "" {
+		fail("defaultbackend", "must not be empty")
+	}
+	caps, _ := conf.GetIntMap("backendmaxqubits")
+	for _, name := range sortedKeys(caps) {
+		if n, limit := caps[name], conf.GetInt("maxqubits"); n <= 0 || n > limit {
+			fail("backendmaxqubits", "%s: %d is not between 1 and maxqubits (%d)", name, n, limit)
+		}
+	}
+	switch s := conf.GetString("qprogstore"); s {
+	case "memory", "file":
+	default:
+		fail("qprogstore", "unknown store %q (memory or file)", s)
+	}
+	return errors.Join(errs...)
+}
+
+// GetIntMap reads a map of ints given either as a map in the config file or
+// as a string of comma-separated name=value pairs, e.g. from the
+// environment. An unset key yields an empty map.
+func (conf *Config) GetIntMap(key string) (map[string]int, error) {
+	m := make(map[string]int)
+	switch v := conf.Get(key).(type) {
+	case nil:
+	case string:
+		for _, pair := range strings.Split(v, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("%q is not a name=value pair", pair)
+			}
+			n, err := cast.ToIntE(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			m[strings.TrimSpace(name)] = n
+		}
+	default:
+		raw, err := cast.ToStringMapE(v)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range raw {
+			n, err := cast.ToIntE(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			m[name] = n
+		}
+	}
+	return m, nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -13,6 +13,11 @@ var (
 	stringType configVarType = "string"
 	intType    configVarType = "int"
 	boolType   configVarType = "bool"
+	// mapType is a map of ints, either "name=1,other=2" or a map in the
+	// config file
+	mapType configVarType = "map"
+	// listType is a comma-separated string or a list in the config file
+	listType configVarType = "list"
 )
 
 var configVars = map[string]configVar{
@@ -26,6 +31,11 @@ var configVars = map[string]configVar{
 		Default: 10,
 		EnvVar:  "GRACEFULSHUTDOWNTIMEOUT",
 	},
+	"localonly": {
+		Type:    boolType,
+		Default: false,
+		EnvVar:  "LOCALONLY",
+	},
 	"debug": {
 		Type:    boolType,
 		Default: false,
@@ -36,4 +46,104 @@ var configVars = map[string]configVar{
 		Default: "templates",
 		EnvVar:  "TEMPLATEFOLDER",
 	},
+	"staticdir": {
+		Type:    stringType,
+		Default: "./public",
+		EnvVar:  "STATICDIR",
+	},
+	"maxqubits": {
+		Type:    intType,
+		Default: 10,
+		EnvVar:  "MAXQUBITS",
+	},
+	"maxshots": {
+		Type:    intType,
+		Default: 10000,
+		EnvVar:  "MAXSHOTS",
+	},
+	"defaultshots": {
+		Type:    intType,
+		Default: 1000,
+		EnvVar:  "DEFAULTSHOTS",
+	},
+	"maxjobshots": {
+		Type:    intType,
+		Default: 1000000,
+		EnvVar:  "MAXJOBSHOTS",
+	},
+	"defaultbackend": {
+		Type:    stringType,
+		Default: "qsim",
+		EnvVar:  "DEFAULTBACKEND",
+	},
+	"backendmaxqubits": {
+		Type:    mapType,
+		Default: "",
+		EnvVar:  "BACKENDMAXQUBITS",
+	},
+	"rendercellsize": {
+		Type:    intType,
+		Default: 60,
+		EnvVar:  "RENDERCELLSIZE",
+	},
+	"qprogstore": {
+		Type:    stringType,
+		Default: "memory",
+		EnvVar:  "QPROGSTORE",
+	},
+	"qprogdir": {
+		Type:    stringType,
+		Default: "qprogs",
+		EnvVar:  "QPROGDIR",
+	},
+	"jobworkers": {
+		Type:    intType,
+		Default: 2,
+		EnvVar:  "JOBWORKERS",
+	},
+	"jobqueuesize": {
+		Type:    intType,
+		Default: 64,
+		EnvVar:  "JOBQUEUESIZE",
+	},
+	"jobretention": {
+		Type:    intType,
+		Default: 600,
+		EnvVar:  "JOBRETENTION",
+	},
+	"resultcachesize": {
+		Type:    intType,
+		Default: 256,
+		EnvVar:  "RESULTCACHESIZE",
+	},
+	"resultcachettl": {
+		Type:    intType,
+		Default: 600,
+		EnvVar:  "RESULTCACHETTL",
+	},
+	"apikeys": {
+		Type:    listType,
+		Default: "",
+		EnvVar:  "APIKEYS",
+	},
+	"ratelimit": {
+		Type:    intType,
+		Default: 300,
+		EnvVar:  "RATELIMIT",
+	},
+	"rateburst": {
+		Type:    intType,
+		Default: 30,
+		EnvVar:  "RATEBURST",
+	},
+	"quotamaxshots": {
+		Type:    intType,
+		Default: 0,
+		EnvVar:  "QUOTAMAXSHOTS",
+	},
+	"quotamaxqubits": {
+		Type:    intType,
+		Default: 0,
+		EnvVar:  "QUOTAMAXQUBITS",
+	},
 }
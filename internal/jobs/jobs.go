@@ -0,0 +1,408 @@
+// Package jobs runs circuit executions in the background. Jobs wait in a
+// bounded queue for a fixed pool of workers, report their progress shot by
+// shot, can be cancelled and are forgotten some time after they finish.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+type (
+	// State is the lifecycle state of a job.
+	State string
+
+	// Request describes the execution a job performs.
+	Request struct {
+		Circuit circuit.Circuit
+		Backend string
+		Shots   int
+	}
+
+	// Status is a snapshot of a job, safe to serialise.
+	Status struct {
+		ID         string         `json:"id"`
+		State      State          `json:"state"`
+		Backend    string         `json:"backend"`
+		Shots      int            `json:"shots"`
+		Completed  int            `json:"completed"` // shots run so far
+		Progress   float64        `json:"progress"`  // Completed/Shots
+		Result     map[string]int `json:"result,omitempty"`
+		Error      string         `json:"error,omitempty"`
+		CreatedAt  time.Time      `json:"created_at"`
+		StartedAt  *time.Time     `json:"started_at,omitempty"`
+		FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	}
+
+	ManagerOptions struct {
+		Logger *logger.Logger
+		// Workers is the number of jobs executed concurrently (0 = 2).
+		Workers int
+		// QueueSize bounds the jobs waiting for a worker (0 = 64).
+		QueueSize int
+		// Retention is how long finished jobs stay queryable (0 = 10m).
+		Retention time.Duration
+		// Pool returns the runner pool of a backend (nil = a new pool of the
+		// registered factory per job).
+		Pool func(backend string) (*simulator.RunnerPool, error)
+		// OnFinish, when set, is called with the final status of every job
+		// that ran, outside of the manager's lock.
+		OnFinish func(Status)
+	}
+
+	// Manager owns the queue, the workers and the job table.
+	Manager struct {
+		logger    *logger.Logger
+		queue     chan *job
+		retention time.Duration
+		pool      func(string) (*simulator.RunnerPool, error)
+		onFinish  func(Status)
+		now       func() time.Time
+
+		mu   sync.Mutex
+		jobs map[string]*job
+
+		wg     sync.WaitGroup
+		ctx    context.Context
+		cancel context.CancelFunc
+	}
+
+	job struct {
+		id        string
+		req       Request
+		pool      *simulator.RunnerPool
+		ctx       context.Context
+		cancel    context.CancelFunc
+		completed atomic.Int64
+
+		// guarded by Manager.mu
+		state      State
+		result     map[string]int
+		err        error
+		createdAt  time.Time
+		startedAt  time.Time
+		finishedAt time.Time
+	}
+)
+
+const (
+	Queued    State = "queued"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+	Cancelled State = "cancelled"
+)
+
+var (
+	// ErrJobNotFound is returned for unknown or expired job IDs.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrQueueFull is returned by Submit when no more jobs can wait.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrClosed is returned by Submit after Close.
+	ErrClosed = errors.New("job manager is closed")
+)
+
+// Finished reports whether the state is final.
+func (s State) Finished() bool { return s == Succeeded || s == Failed || s == Cancelled }
+
+// NewManager starts the workers of a new job manager.
+func NewManager(options ManagerOptions) *Manager {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = 2
+	}
+	queueSize := options.QueueSize
+	if queueSize <= 0 {
+		queueSize = 64
+	}
+	retention := options.Retention
+	if retention <= 0 {
+		retention = 10 * time.Minute
+	}
+	pool := options.Pool
+	if pool == nil {
+		pool = newPool
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		logger:    options.Logger.SpawnForService("jobs"),
+		queue:     make(chan *job, queueSize),
+		retention: retention,
+		pool:      pool,
+		onFinish:  options.OnFinish,
+		now:       time.Now,
+		jobs:      make(map[string]*job),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for range workers {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	return m
+}
+
+// newPool creates a pool of the runners registered for a backend.
+func newPool(backend string) (*simulator.RunnerPool, error) {
+	factory, err := simulator.GetRunnerFactory(backend)
+	if err != nil {
+		return nil, err
+	}
+	return simulator.NewRunnerPool(factory), nil
+}
+
+// Submit validates the request against a runner of its backend and queues
+// the job.
+func (m *Manager) Submit(req Request) (Status, error) {
+	if req.Circuit == nil {
+		return Status{}, fmt.Errorf("job has no circuit")
+	}
+	if req.Shots <= 0 {
+		return Status{}, fmt.Errorf("shots must be positive, got %d", req.Shots)
+	}
+	pool, err := m.pool(req.Backend)
+	if err != nil {
+		return Status{}, err
+	}
+	runner, err := pool.Get()
+	if err != nil {
+		return Status{}, err
+	}
+	if v, ok := runner.(simulator.ValidatingRunner); ok {
+		err = v.ValidateCircuit(req.Circuit)
+	}
+	pool.Put(runner)
+	if err != nil {
+		return Status{}, fmt.Errorf("backend %s cannot run the circuit: %w", req.Backend, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx.Err() != nil {
+		return Status{}, ErrClosed
+	}
+	m.prune()
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &job{
+		id:        uuid.Must(uuid.NewRandom()).String(),
+		req:       req,
+		pool:      pool,
+		ctx:       ctx,
+		cancel:    cancel,
+		state:     Queued,
+		createdAt: m.now(),
+	}
+	select {
+	case m.queue <- j:
+	default:
+		cancel()
+		return Status{}, ErrQueueFull
+	}
+	m.jobs[j.id] = j
+	m.logger.Debug().Str("job", j.id).Str("backend", req.Backend).Int("shots", req.Shots).Msg("job queued")
+	return m.status(j), nil
+}
+
+// Get returns the status of a job.
+func (m *Manager) Get(id string) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+	return m.status(j), nil
+}
+
+// List returns the status of all retained jobs, oldest first.
+func (m *Manager) List() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	list := make([]Status, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, m.status(j))
+	}
+	sort.Slice(list, func(i, k int) bool {
+		if !list[i].CreatedAt.Equal(list[k].CreatedAt) {
+			return list[i].CreatedAt.Before(list[k].CreatedAt)
+		}
+		return list[i].ID < list[k].ID
+	})
+	return list
+}
+
+// QueueDepth returns the number of jobs waiting for a worker.
+func (m *Manager) QueueDepth() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, j := range m.jobs {
+		if j.state == Queued {
+			n++
+		}
+	}
+	return n
+}
+
+// Cancel stops a queued or running job. Cancelling a finished job is a
+// no-op that returns its final status.
+func (m *Manager) Cancel(id string) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+	if !j.state.Finished() {
+		j.cancel()
+		if j.state == Queued {
+			// The worker that dequeues it will skip it.
+			m.finish(j, nil, context.Canceled)
+		}
+	}
+	return m.status(j), nil
+}
+
+// Close cancels all jobs and waits for the workers to stop.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.cancel()
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if j.state == Queued {
+			m.finish(j, nil, context.Canceled)
+		}
+	}
+}
+
+func (m *Manager) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case j := <-m.queue:
+			m.run(j)
+		}
+	}
+}
+
+// run executes the shots of a job one by one so progress and cancellation
+// are observed between shots; contextual runners also see cancellation
+// inside a shot.
+func (m *Manager) run(j *job) {
+	m.mu.Lock()
+	if j.state != Queued {
+		m.mu.Unlock()
+		return
+	}
+	j.state = Running
+	j.startedAt = m.now()
+	m.mu.Unlock()
+
+	hist := make(map[string]int)
+	runner, err := j.pool.Get()
+	if err == nil {
+		err = j.shots(runner, hist)
+		j.pool.Put(runner)
+	}
+
+	m.mu.Lock()
+	m.finish(j, hist, err)
+	j.cancel()
+	status := m.status(j)
+	m.mu.Unlock()
+	m.logger.Debug().Str("job", j.id).Str("state", string(status.State)).Msg("job finished")
+	if m.onFinish != nil {
+		m.onFinish(status)
+	}
+}
+
+// shots runs the shots of a job on runner and counts their results in hist.
+func (j *job) shots(runner simulator.OneShotRunner, hist map[string]int) error {
+	runOnce := func() (string, error) { return runner.RunOnce(j.req.Circuit) }
+	if cr, ok := runner.(simulator.ContextualRunner); ok {
+		runOnce = func() (string, error) { return cr.RunOnceWithContext(j.ctx, j.req.Circuit) }
+	}
+	for shot := 0; shot < j.req.Shots; shot++ {
+		if err := j.ctx.Err(); err != nil {
+			return err
+		}
+		res, err := runOnce()
+		if err != nil {
+			if ctxErr := j.ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("shot %d: %w", shot, err)
+		}
+		hist[res]++
+		j.completed.Add(1)
+	}
+	return nil
+}
+
+// finish records the outcome of a job; m.mu must be held.
+func (m *Manager) finish(j *job, hist map[string]int, err error) {
+	j.finishedAt = m.now()
+	switch {
+	case err == nil:
+		j.state, j.result = Succeeded, hist
+	case errors.Is(err, context.Canceled):
+		j.state = Cancelled
+	default:
+		j.state, j.err = Failed, err
+	}
+}
+
+// prune forgets jobs that finished more than the retention ago; m.mu must
+// be held.
+func (m *Manager) prune() {
+	cutoff := m.now().Add(-m.retention)
+	for id, j := range m.jobs {
+		if j.state.Finished() && j.finishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// status snapshots a job; m.mu must be held.
+func (m *Manager) status(j *job) Status {
+	s := Status{
+		ID:        j.id,
+		State:     j.state,
+		Backend:   j.req.Backend,
+		Shots:     j.req.Shots,
+		Completed: int(j.completed.Load()),
+		Result:    j.result,
+		CreatedAt: j.createdAt,
+	}
+	s.Progress = float64(s.Completed) / float64(s.Shots)
+	if j.err != nil {
+		s.Error = j.err.Error()
+	}
+	if !j.startedAt.IsZero() {
+		t := j.startedAt
+		s.StartedAt = &t
+	}
+	if !j.finishedAt.IsZero() {
+		t := j.finishedAt
+		s.FinishedAt = &t
+	}
+	return s
+}
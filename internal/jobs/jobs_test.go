@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/simulator"
+	_ "github.com/kegliz/qplay/qc/simulator/qsim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gate blocks every shot of the "jobs-test-gated" runner until released.
+var gate = make(chan struct{})
+
+// gatedRunner waits for a token from gate (or cancellation) on every shot.
+type gatedRunner struct{}
+
+func (gatedRunner) RunOnce(c circuit.Circuit) (string, error) {
+	return gatedRunner{}.RunOnceWithContext(context.Background(), c)
+}
+
+func (gatedRunner) RunOnceWithContext(ctx context.Context, _ circuit.Circuit) (string, error) {
+	select {
+	case <-gate:
+		return "1", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// failingRunner fails every shot.
+type failingRunner struct{}
+
+func (failingRunner) RunOnce(circuit.Circuit) (string, error) { return "", errors.New("boom") }
+
+func init() {
+	simulator.MustRegisterRunner("jobs-test-gated", func() simulator.OneShotRunner { return gatedRunner{} })
+	simulator.MustRegisterRunner("jobs-test-failing", func() simulator.OneShotRunner { return failingRunner{} })
+}
+
+func newTestCircuit(t *testing.T) circuit.Circuit {
+	t.Helper()
+	b := builder.New(builder.Q(1), builder.C(1))
+	b.X(0).Measure(0, 0)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+	return c
+}
+
+func newTestManager(workers, queue int) *Manager {
+	return NewManager(ManagerOptions{
+		Logger:    logger.NewLogger(logger.LoggerOptions{}),
+		Workers:   workers,
+		QueueSize: queue,
+	})
+}
+
+// waitFor polls the job until cond holds.
+func waitFor(t *testing.T, m *Manager, id string, cond func(Status) bool) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s, err := m.Get(id)
+		require.NoError(t, err)
+		if cond(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s stuck in %+v", id, s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManager_Succeeds(t *testing.T) {
+	finished := make(chan Status, 1)
+	m := NewManager(ManagerOptions{
+		Logger:   logger.NewLogger(logger.LoggerOptions{}),
+		Workers:  1,
+		OnFinish: func(s Status) { finished <- s },
+	})
+	defer m.Close()
+
+	s, err := m.Submit(Request{Circuit: newTestCircuit(t), Backend: "qsim", Shots: 50})
+	require.NoError(t, err)
+	assert.Equal(t, Queued, s.State)
+
+	s = waitFor(t, m, s.ID, func(s Status) bool { return s.State.Finished() })
+	assert.Equal(t, Succeeded, s.State)
+	assert.Equal(t, 50, s.Completed)
+	assert.Equal(t, 1.0, s.Progress)
+	assert.Equal(t, map[string]int{"1": 50}, s.Result)
+	assert.NotNil(t, s.StartedAt)
+	assert.NotNil(t, s.FinishedAt)
+	assert.Equal(t, s, <-finished)
+}
+
+func TestManager_ProgressAndCancel(t *testing.T) {
+	m := newTestManager(1, 4)
+	defer m.Close()
+
+	running, err := m.Submit(Request{Circuit: newTestCircuit(t), Backend: "jobs-test-gated", Shots: 10})
+	require.NoError(t, err)
+	queued, err := m.Submit(Request{Circuit: newTestCircuit(t), Backend: "jobs-test-gated", Shots: 10})
+	require.NoError(t, err)
+
+	gate <- struct{}{}
+	gate <- struct{}{}
+	s := waitFor(t, m, running.ID, func(s Status) bool { return s.Completed == 2 })
+	assert.Equal(t, Running, s.State)
+	assert.Equal(t, 1, m.QueueDepth())
+	assert.InDelta(t, 0.2, s.Progress, 1e-9)
+
+	// The queued job never starts, the running one stops inside its shot.
+	s, err = m.Cancel(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Cancelled, s.State)
+	assert.Zero(t, m.QueueDepth())
+	_, err = m.Cancel(running.ID)
+	require.NoError(t, err)
+	s = waitFor(t, m, running.ID, func(s Status) bool { return s.State.Finished() })
+	assert.Equal(t, Cancelled, s.State)
+	assert.Equal(t, 2, s.Completed)
+	assert.Nil(t, s.Result)
+
+	// Cancelling again is harmless.
+	s, err = m.Cancel(running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Cancelled, s.State)
+
+	list := m.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, running.ID, list[0].ID, "jobs are listed oldest first")
+}
+
+func TestManager_Errors(t *testing.T) {
+	m := newTestManager(1, 1)
+	defer m.Close()
+	c := newTestCircuit(t)
+
+	_, err := m.Submit(Request{Circuit: c, Backend: "nope", Shots: 1})
+	assert.Error(t, err)
+	_, err = m.Submit(Request{Circuit: c, Backend: "qsim", Shots: 0})
+	assert.Error(t, err)
+	_, err = m.Get("nope")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	_, err = m.Cancel("nope")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	failed, err := m.Submit(Request{Circuit: c, Backend: "jobs-test-failing", Shots: 3})
+	require.NoError(t, err)
+	s := waitFor(t, m, failed.ID, func(s Status) bool { return s.State.Finished() })
+	assert.Equal(t, Failed, s.State)
+	assert.Contains(t, s.Error, "boom")
+
+	// One job runs, one waits, the next is rejected.
+	first, err := m.Submit(Request{Circuit: c, Backend: "jobs-test-gated", Shots: 1})
+	require.NoError(t, err)
+	waitFor(t, m, first.ID, func(s Status) bool { return s.State == Running })
+	_, err = m.Submit(Request{Circuit: c, Backend: "jobs-test-gated", Shots: 1})
+	require.NoError(t, err)
+	_, err = m.Submit(Request{Circuit: c, Backend: "jobs-test-gated", Shots: 1})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	m.Close()
+	for _, s := range m.List() {
+		assert.True(t, s.State.Finished(), "Close finishes job %s", s.ID)
+	}
+	_, err = m.Submit(Request{Circuit: c, Backend: "qsim", Shots: 1})
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestManager_Retention(t *testing.T) {
+	m := newTestManager(1, 4)
+	defer m.Close()
+
+	s, err := m.Submit(Request{Circuit: newTestCircuit(t), Backend: "qsim", Shots: 1})
+	require.NoError(t, err)
+	waitFor(t, m, s.ID, func(s Status) bool { return s.State.Finished() })
+
+	m.mu.Lock()
+	m.now = func() time.Time { return time.Now().Add(time.Hour) }
+	m.mu.Unlock()
+	_, err = m.Get(s.ID)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+	assert.Empty(t, m.List())
+}
@@ -0,0 +1,332 @@
+// Package metrics is a small collection of counters and histograms exported
+// in the Prometheus text exposition format (version 0.0.4). It covers what
+// the web service needs without pulling in a client library: labelled
+// counters and histograms updated in place, and gauges or counters computed
+// on every scrape from a callback.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of Write's output.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are latency buckets in seconds suited to HTTP handlers.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type (
+	// Registry holds metric families in registration order.
+	Registry struct {
+		mu       sync.Mutex
+		families []family
+		names    map[string]bool
+	}
+
+	// Sample is one value reported by a callback, with label values in the
+	// order the labels were declared.
+	Sample struct {
+		Labels []string
+		Value  float64
+	}
+
+	family interface {
+		write(w io.Writer) error
+	}
+
+	desc struct {
+		name, help, typ string
+		labels          []string
+	}
+
+	// CounterVec is a family of counters partitioned by label values.
+	CounterVec struct {
+		desc
+		mu     sync.Mutex
+		values map[string]*Counter
+	}
+
+	// Counter is a monotonically increasing value.
+	Counter struct {
+		labels []string
+		mu     sync.Mutex
+		v      float64
+	}
+
+	// HistogramVec is a family of histograms partitioned by label values.
+	HistogramVec struct {
+		desc
+		buckets []float64
+		mu      sync.Mutex
+		values  map[string]*Histogram
+	}
+
+	// Histogram counts observations into cumulative buckets.
+	Histogram struct {
+		labels  []string
+		buckets []float64
+		mu      sync.Mutex
+		counts  []uint64 // per bucket, not cumulative; last is +Inf
+		sum     float64
+		n       uint64
+	}
+
+	funcFamily struct {
+		desc
+		fn func() []Sample
+	}
+)
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	r.families = append(r.families, f)
+}
+
+// Counter registers a counter family.
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name, help, "counter", labels}, values: make(map[string]*Counter)}
+	r.register(name, c)
+	return c
+}
+
+// Histogram registers a histogram family with the given upper bounds, which
+// must be sorted ascending.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{desc: desc{name, help, "histogram", labels}, buckets: buckets, values: make(map[string]*Histogram)}
+	r.register(name, h)
+	return h
+}
+
+// GaugeFunc registers a gauge family whose samples fn computes on every scrape.
+func (r *Registry) GaugeFunc(name, help string, labels []string, fn func() []Sample) {
+	r.register(name, &funcFamily{desc{name, help, "gauge", labels}, fn})
+}
+
+// CounterFunc registers a counter family whose samples fn computes on every
+// scrape, for counters maintained elsewhere.
+func (r *Registry) CounterFunc(name, help string, labels []string, fn func() []Sample) {
+	r.register(name, &funcFamily{desc{name, help, "counter", labels}, fn})
+}
+
+// Write writes every family in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// With returns the counter of the given label values, creating it on first use.
+func (v *CounterVec) With(labels ...string) *Counter {
+	v.check(labels)
+	key := strings.Join(labels, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.values[key]
+	if !ok {
+		c = &Counter{labels: labels}
+		v.values[key] = c
+	}
+	return c
+}
+
+// Inc adds one.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add adds d, which must not be negative.
+func (c *Counter) Add(d float64) {
+	if d < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	c.v += d
+	c.mu.Unlock()
+}
+
+// Value returns the current count.
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.v
+}
+
+func (v *CounterVec) write(w io.Writer) error {
+	v.mu.Lock()
+	counters := make([]*Counter, 0, len(v.values))
+	for _, c := range v.values {
+		counters = append(counters, c)
+	}
+	v.mu.Unlock()
+	sortByLabels(counters, func(c *Counter) []string { return c.labels })
+
+	if err := v.header(w); err != nil {
+		return err
+	}
+	for _, c := range counters {
+		if err := v.sample(w, "", c.labels, nil, c.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// With returns the histogram of the given label values, creating it on first use.
+func (v *HistogramVec) With(labels ...string) *Histogram {
+	v.check(labels)
+	key := strings.Join(labels, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	h, ok := v.values[key]
+	if !ok {
+		h = &Histogram{labels: labels, buckets: v.buckets, counts: make([]uint64, len(v.buckets)+1)}
+		v.values[key] = h
+	}
+	return h
+}
+
+// Observe records one value.
+func (h *Histogram) Observe(x float64) {
+	i := sort.SearchFloat64s(h.buckets, x)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += x
+	h.n++
+	h.mu.Unlock()
+}
+
+func (v *HistogramVec) write(w io.Writer) error {
+	v.mu.Lock()
+	hists := make([]*Histogram, 0, len(v.values))
+	for _, h := range v.values {
+		hists = append(hists, h)
+	}
+	v.mu.Unlock()
+	sortByLabels(hists, func(h *Histogram) []string { return h.labels })
+
+	if err := v.header(w); err != nil {
+		return err
+	}
+	for _, h := range hists {
+		h.mu.Lock()
+		counts, sum, n := append([]uint64(nil), h.counts...), h.sum, h.n
+		h.mu.Unlock()
+		var cum uint64
+		for i, le := range v.buckets {
+			cum += counts[i]
+			if err := v.sample(w, "_bucket", h.labels, []string{"le", formatFloat(le)}, float64(cum)); err != nil {
+				return err
+			}
+		}
+		if err := v.sample(w, "_bucket", h.labels, []string{"le", "+Inf"}, float64(n)); err != nil {
+			return err
+		}
+		if err := v.sample(w, "_sum", h.labels, nil, sum); err != nil {
+			return err
+		}
+		if err := v.sample(w, "_count", h.labels, nil, float64(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *funcFamily) write(w io.Writer) error {
+	samples := f.fn()
+	for _, s := range samples {
+		f.check(s.Labels)
+	}
+	sortByLabels(samples, func(s Sample) []string { return s.Labels })
+	if err := f.header(w); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		if err := f.sample(w, "", s.Labels, nil, s.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *desc) check(labels []string) {
+	if len(labels) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", d.name, len(d.labels), len(labels)))
+	}
+}
+
+func (d *desc) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+	return err
+}
+
+// sample writes one line; extra is an additional label name/value pair.
+func (d *desc) sample(w io.Writer, suffix string, values, extra []string, v float64) error {
+	var sb strings.Builder
+	sb.WriteString(d.name)
+	sb.WriteString(suffix)
+	if len(values)+len(extra) > 0 {
+		sb.WriteByte('{')
+		for i, name := range d.labels {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, "%s=\"%s\"", name, escapeLabel(values[i]))
+		}
+		if len(extra) == 2 {
+			if len(values) > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, "%s=\"%s\"", extra[0], extra[1])
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(v))
+	sb.WriteByte('\n')
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func sortByLabels[T any](s []T, labels func(T) []string) {
+	sort.Slice(s, func(i, j int) bool {
+		return strings.Join(labels(s[i]), "\xff") < strings.Join(labels(s[j]), "\xff")
+	})
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	requests := r.Counter("http_requests_total", "Requests served.", "route", "code")
+	latency := r.Histogram("http_request_duration_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	r.GaugeFunc("queue_depth", "Jobs waiting.", nil, func() []Sample { return []Sample{{Value: 3}} })
+	r.CounterFunc("runner_runs_total", "Runs by outcome.", []string{"outcome"}, func() []Sample {
+		return []Sample{{Labels: []string{"success"}, Value: 9}, {Labels: []string{"failure"}, Value: 1}}
+	})
+
+	requests.With("/api/execute", "200").Inc()
+	requests.With("/api/execute", "200").Add(2)
+	requests.With("/a\"b", "500").Inc()
+	latency.With("/x").Observe(0.05)
+	latency.With("/x").Observe(0.5)
+	latency.With("/x").Observe(7)
+
+	var sb strings.Builder
+	require.NoError(t, r.Write(&sb))
+	assert.Equal(t, `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="/a\"b",code="500"} 1
+http_requests_total{route="/api/execute",code="200"} 3
+# HELP http_request_duration_seconds Request latency.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/x",le="0.1"} 1
+http_request_duration_seconds_bucket{route="/x",le="1"} 2
+http_request_duration_seconds_bucket{route="/x",le="+Inf"} 3
+http_request_duration_seconds_sum{route="/x"} 7.55
+http_request_duration_seconds_count{route="/x"} 3
+# HELP queue_depth Jobs waiting.
+# TYPE queue_depth gauge
+queue_depth 3
+# HELP runner_runs_total Runs by outcome.
+# TYPE runner_runs_total counter
+runner_runs_total{outcome="failure"} 1
+runner_runs_total{outcome="success"} 9
+`, sb.String())
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("c", "", "a")
+	assert.Panics(t, func() { r.Counter("c", "") }, "duplicate name")
+	assert.Panics(t, func() { c.With("x", "y") }, "label count")
+	assert.Panics(t, func() { c.With("x").Add(-1) }, "negative increment")
+}
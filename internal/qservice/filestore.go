@@ -0,0 +1,149 @@
+package qservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// fileStore keeps one JSON document per program in a directory, so
+// programs survive restarts.
+type fileStore struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// NewFileProgramStore returns a program store writing JSON files to dir,
+// which is created if needed.
+func NewFileProgramStore(dir string) (ProgramStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating program directory: %w", err)
+	}
+	return &fileStore{dir: dir}, nil
+}
+
+// path maps an ID to its file; IDs that are not UUIDs cannot exist, which
+// also keeps callers from escaping the directory.
+func (s *fileStore) path(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", ErrProgramNotFound
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *fileStore) Create(p *Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, err := s.path(p.ID)
+	if err != nil {
+		return fmt.Errorf("program ID %q is not a UUID", p.ID)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return ErrProgramExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return s.write(path, p)
+}
+
+func (s *fileStore) Get(id string) (*Program, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(path)
+}
+
+func (s *fileStore) Update(p *Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, err := s.path(p.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return ErrProgramNotFound
+	}
+	return s.write(path, p)
+}
+
+func (s *fileStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrProgramNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) List(offset, limit int) ([]*Program, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []*Program
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		p, err := s.read(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, 0, err
+		}
+		all = append(all, p)
+	}
+	return page(all, offset, limit), len(all), nil
+}
+
+func (s *fileStore) read(path string) (*Program, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrProgramNotFound
+		}
+		return nil, err
+	}
+	var p Program
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", filepath.Base(path), err)
+	}
+	return &p, nil
+}
+
+// write stores p atomically by renaming a temporary file over path.
+func (s *fileStore) write(path string, p *Program) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
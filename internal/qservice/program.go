@@ -0,0 +1,59 @@
+package qservice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kegliz/qplay/qc/spec"
+)
+
+type (
+	// ProgramValue is the user-supplied part of a quantum program.
+	ProgramValue struct {
+		Name    string       `json:"name,omitempty"`
+		Circuit spec.Circuit `json:"circuit"`
+	}
+
+	// ProgramIDValue wraps the identifier of a stored program.
+	ProgramIDValue struct {
+		ID string `json:"id"`
+	}
+
+	// Program is a stored quantum program.
+	Program struct {
+		ID string `json:"id"`
+		ProgramValue
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// ProgramList is one page of stored programs.
+	ProgramList struct {
+		Programs []*Program `json:"programs"`
+		Total    int        `json:"total"`
+		Offset   int        `json:"offset"`
+		Limit    int        `json:"limit"`
+	}
+)
+
+var (
+	// ErrProgramNotFound is returned for unknown program IDs.
+	ErrProgramNotFound = errors.New("program not found")
+	// ErrProgramExists is returned when creating a program whose ID is taken.
+	ErrProgramExists = errors.New("program already exists")
+	// ErrInvalidProgram wraps validation failures of a ProgramValue.
+	ErrInvalidProgram = errors.New("invalid program")
+)
+
+// clone returns a deep copy so stores never share memory with callers.
+func (p *Program) clone() *Program {
+	c := *p
+	c.Circuit.Gates = make([]spec.Gate, len(p.Circuit.Gates))
+	for i, g := range p.Circuit.Gates {
+		g.Qubits = append([]int(nil), g.Qubits...)
+		g.Clbits = append([]int(nil), g.Clbits...)
+		g.Params = append([]float64(nil), g.Params...)
+		c.Circuit.Gates[i] = g
+	}
+	return &c
+}
@@ -0,0 +1,148 @@
+package qservice
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/qc/renderer"
+)
+
+type (
+	// Service manages stored quantum programs.
+	Service interface {
+		SaveProgram(l *logger.Logger, p *ProgramValue) (string, error)
+		GetProgram(l *logger.Logger, id string) (*Program, error)
+		UpdateProgram(l *logger.Logger, id string, p *ProgramValue) (*Program, error)
+		DeleteProgram(l *logger.Logger, id string) error
+		ListPrograms(l *logger.Logger, offset, limit int) (*ProgramList, error)
+		RenderCircuit(l *logger.Logger, id string) (image.Image, error)
+	}
+
+	ServiceOptions struct {
+		Logger *logger.Logger
+		Store  ProgramStore
+		// CellSize is the renderer cell size in pixels (0 = 60).
+		CellSize int
+		// MaxQubits bounds the circuits of stored programs (0 = DefaultMaxQubits).
+		MaxQubits int
+	}
+
+	service struct {
+		logger    *logger.Logger
+		store     ProgramStore
+		cellSize  int
+		maxQubits int
+	}
+)
+
+// Paging limits of ListPrograms.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// NewService creates a new program service.
+func NewService(options ServiceOptions) Service {
+	cell := options.CellSize
+	if cell <= 0 {
+		cell = 60
+	}
+	return &service{
+		logger:    options.Logger.SpawnForService("qservice"),
+		store:     options.Store,
+		cellSize:  cell,
+		maxQubits: options.MaxQubits,
+	}
+}
+
+// SaveProgram validates and stores a new program and returns its ID.
+func (s *service) SaveProgram(l *logger.Logger, p *ProgramValue) (string, error) {
+	if err := s.validate(p); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	prog := &Program{
+		ID:           uuid.Must(uuid.NewRandom()).String(),
+		ProgramValue: *p,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := s.store.Create(prog); err != nil {
+		return "", err
+	}
+	l.Debug().Str("id", prog.ID).Msg("program saved")
+	return prog.ID, nil
+}
+
+// GetProgram returns a stored program.
+func (s *service) GetProgram(l *logger.Logger, id string) (*Program, error) {
+	return s.store.Get(id)
+}
+
+// UpdateProgram replaces the value of a stored program.
+func (s *service) UpdateProgram(l *logger.Logger, id string, p *ProgramValue) (*Program, error) {
+	if err := s.validate(p); err != nil {
+		return nil, err
+	}
+	prog, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	prog.ProgramValue = *p
+	prog.UpdatedAt = time.Now().UTC()
+	if err := s.store.Update(prog); err != nil {
+		return nil, err
+	}
+	l.Debug().Str("id", id).Msg("program updated")
+	return prog, nil
+}
+
+// DeleteProgram removes a stored program.
+func (s *service) DeleteProgram(l *logger.Logger, id string) error {
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	l.Debug().Str("id", id).Msg("program deleted")
+	return nil
+}
+
+// ListPrograms returns a page of programs, oldest first. A non-positive
+// limit selects DefaultPageSize; limits above MaxPageSize are capped.
+func (s *service) ListPrograms(l *logger.Logger, offset, limit int) (*ProgramList, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+	programs, total, err := s.store.List(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &ProgramList{Programs: programs, Total: total, Offset: offset, Limit: limit}, nil
+}
+
+// RenderCircuit draws the circuit of a stored program.
+func (s *service) RenderCircuit(l *logger.Logger, id string) (image.Image, error) {
+	prog, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := BuildCircuit(&prog.Circuit, s.maxQubits)
+	if err != nil {
+		return nil, fmt.Errorf("building circuit of program %s: %w", id, err)
+	}
+	return renderer.NewRenderer(s.cellSize).Render(c)
+}
+
+// validate checks that the program describes a buildable circuit.
+func (s *service) validate(p *ProgramValue) error {
+	if _, err := BuildCircuit(&p.Circuit, s.maxQubits); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidProgram, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package qservice
+
+import (
+	"testing"
+
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/qc/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	l := logger.NewLogger(logger.LoggerOptions{})
+	s := NewService(ServiceOptions{Logger: l, Store: NewProgramStore()})
+
+	bell := &ProgramValue{Name: "bell", Circuit: spec.Circuit{
+		Qubits: 2,
+		Gates:  []spec.Gate{{Type: "H", Qubits: []int{0}}, {Type: "CNOT", Qubits: []int{0, 1}, Step: 1}},
+	}}
+	id, err := s.SaveProgram(l, bell)
+	require.NoError(err)
+	assert.NotEmpty(id)
+
+	prog, err := s.GetProgram(l, id)
+	require.NoError(err)
+	assert.Equal("bell", prog.Name)
+	assert.False(prog.CreatedAt.IsZero())
+
+	img, err := s.RenderCircuit(l, id)
+	require.NoError(err)
+	assert.Greater(img.Bounds().Dx(), 0)
+
+	bell.Name = "bell pair"
+	updated, err := s.UpdateProgram(l, id, bell)
+	require.NoError(err)
+	assert.Equal("bell pair", updated.Name)
+	assert.Equal(prog.CreatedAt, updated.CreatedAt)
+	assert.False(updated.UpdatedAt.Before(prog.UpdatedAt))
+
+	_, err = s.SaveProgram(l, &ProgramValue{Circuit: spec.Circuit{Qubits: 0}})
+	assert.ErrorIs(err, ErrInvalidProgram)
+	_, err = s.SaveProgram(l, &ProgramValue{Circuit: spec.Circuit{Qubits: DefaultMaxQubits + 1}})
+	assert.ErrorIs(err, ErrInvalidProgram)
+	_, err = s.SaveProgram(l, &ProgramValue{Circuit: spec.Circuit{Qubits: 1, Gates: []spec.Gate{{Type: "CNOT", Qubits: []int{0}}}}})
+	assert.ErrorIs(err, ErrInvalidProgram)
+	_, err = s.UpdateProgram(l, "nope", bell)
+	assert.ErrorIs(err, ErrProgramNotFound)
+
+	for range 3 {
+		_, err := s.SaveProgram(l, bell)
+		require.NoError(err)
+	}
+	list, err := s.ListPrograms(l, 1, 2)
+	require.NoError(err)
+	assert.Equal(4, list.Total)
+	assert.Len(list.Programs, 2)
+	list, err = s.ListPrograms(l, -1, 1000)
+	require.NoError(err)
+	assert.Equal(0, list.Offset)
+	assert.Equal(MaxPageSize, list.Limit)
+
+	require.NoError(s.DeleteProgram(l, id))
+	_, err = s.RenderCircuit(l, id)
+	assert.ErrorIs(err, ErrProgramNotFound)
+}
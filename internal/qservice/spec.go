@@ -0,0 +1,24 @@
+package qservice
+
+import (
+	"fmt"
+
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/spec"
+)
+
+// DefaultMaxQubits is the largest circuit the web API accepts unless
+// configured otherwise.
+const DefaultMaxQubits = 10
+
+// BuildCircuit checks a spec against the qubit limit of the web API
+// (0 = DefaultMaxQubits) and builds it.
+func BuildCircuit(s *spec.Circuit, maxQubits int) (circuit.Circuit, error) {
+	if maxQubits <= 0 {
+		maxQubits = DefaultMaxQubits
+	}
+	if s.Qubits > maxQubits {
+		return nil, fmt.Errorf("qubits: %d exceeds the limit of %d", s.Qubits, maxQubits)
+	}
+	return s.Build()
+}
@@ -0,0 +1,100 @@
+package qservice
+
+import (
+	"sort"
+	"sync"
+)
+
+// ProgramStore persists programs. Implementations must be safe for
+// concurrent use and return ErrProgramNotFound for unknown IDs.
+type ProgramStore interface {
+	// Create stores a new program; it returns ErrProgramExists if the ID
+	// is already taken.
+	Create(p *Program) error
+	Get(id string) (*Program, error)
+	// Update replaces an existing program.
+	Update(p *Program) error
+	Delete(id string) error
+	// List returns up to limit programs, oldest first, starting at offset,
+	// and the total number of programs.
+	List(offset, limit int) ([]*Program, int, error)
+}
+
+// memoryStore keeps programs in a map; contents are lost on restart.
+type memoryStore struct {
+	mu       sync.RWMutex
+	programs map[string]*Program
+}
+
+// NewProgramStore returns an in-memory program store.
+func NewProgramStore() ProgramStore {
+	return &memoryStore{programs: make(map[string]*Program)}
+}
+
+func (s *memoryStore) Create(p *Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.programs[p.ID]; ok {
+		return ErrProgramExists
+	}
+	s.programs[p.ID] = p.clone()
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*Program, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.programs[id]
+	if !ok {
+		return nil, ErrProgramNotFound
+	}
+	return p.clone(), nil
+}
+
+func (s *memoryStore) Update(p *Program) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.programs[p.ID]; !ok {
+		return ErrProgramNotFound
+	}
+	s.programs[p.ID] = p.clone()
+	return nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.programs[id]; !ok {
+		return ErrProgramNotFound
+	}
+	delete(s.programs, id)
+	return nil
+}
+
+func (s *memoryStore) List(offset, limit int) ([]*Program, int, error) {
+	s.mu.RLock()
+	all := make([]*Program, 0, len(s.programs))
+	for _, p := range s.programs {
+		all = append(all, p.clone())
+	}
+	s.mu.RUnlock()
+	return page(all, offset, limit), len(all), nil
+}
+
+// page sorts programs oldest first and cuts out [offset, offset+limit).
+func page(all []*Program, offset, limit int) []*Program {
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.Before(all[j].CreatedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+	if offset >= len(all) {
+		return []*Program{}
+	}
+	end := len(all)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return all[offset:end]
+}
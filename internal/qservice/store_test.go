@@ -0,0 +1,118 @@
+package qservice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kegliz/qplay/qc/spec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProgram(name string, created time.Time) *Program {
+	return &Program{
+		ID: uuid.Must(uuid.NewRandom()).String(),
+		ProgramValue: ProgramValue{
+			Name: name,
+			Circuit: spec.Circuit{
+				Qubits: 2,
+				Gates:  []spec.Gate{{Type: "H", Qubits: []int{0}}, {Type: "CNOT", Qubits: []int{0, 1}, Step: 1}},
+			},
+		},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+}
+
+// testProgramStore runs the behaviour every ProgramStore must share.
+func testProgramStore(t *testing.T, store ProgramStore) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for i, name := range []string{"bell", "ghz", "grover"} {
+		p := newTestProgram(name, start.Add(time.Duration(i)*time.Minute))
+		require.NoError(store.Create(p))
+		ids = append(ids, p.ID)
+	}
+
+	got, err := store.Get(ids[0])
+	require.NoError(err)
+	assert.Equal("bell", got.Name)
+
+	// Create never overwrites.
+	dup := newTestProgram("impostor", start)
+	dup.ID = ids[0]
+	assert.ErrorIs(store.Create(dup), ErrProgramExists)
+	got, err = store.Get(ids[0])
+	require.NoError(err)
+	assert.Equal("bell", got.Name)
+	assert.Equal([]int{0, 1}, got.Circuit.Gates[1].Qubits)
+
+	// Stored programs are copies.
+	got.Circuit.Gates[1].Qubits[0] = 7
+	again, err := store.Get(ids[0])
+	require.NoError(err)
+	assert.Equal(0, again.Circuit.Gates[1].Qubits[0])
+
+	got.Name = "bell state"
+	require.NoError(store.Update(got))
+	got, err = store.Get(ids[0])
+	require.NoError(err)
+	assert.Equal("bell state", got.Name)
+
+	// Paging is oldest first.
+	page1, total, err := store.List(0, 2)
+	require.NoError(err)
+	assert.Equal(3, total)
+	require.Len(page1, 2)
+	assert.Equal(ids[0], page1[0].ID)
+	assert.Equal(ids[1], page1[1].ID)
+	page2, _, err := store.List(2, 2)
+	require.NoError(err)
+	require.Len(page2, 1)
+	assert.Equal(ids[2], page2[0].ID)
+	empty, _, err := store.List(5, 2)
+	require.NoError(err)
+	assert.Empty(empty)
+
+	require.NoError(store.Delete(ids[1]))
+	_, err = store.Get(ids[1])
+	assert.ErrorIs(err, ErrProgramNotFound)
+	assert.ErrorIs(store.Delete(ids[1]), ErrProgramNotFound)
+	missing := newTestProgram("missing", start)
+	assert.ErrorIs(store.Update(missing), ErrProgramNotFound)
+	_, total, err = store.List(0, 0)
+	require.NoError(err)
+	assert.Equal(2, total)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testProgramStore(t, NewProgramStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "qprogs")
+	store, err := NewFileProgramStore(dir)
+	require.NoError(t, err)
+	testProgramStore(t, store)
+
+	// Programs survive a restart.
+	reopened, err := NewFileProgramStore(dir)
+	require.NoError(t, err)
+	_, total, err := reopened.List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	// IDs cannot escape the directory.
+	_, err = reopened.Get("../qprogs")
+	assert.ErrorIs(t, err, ErrProgramNotFound)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+}
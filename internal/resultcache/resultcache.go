@@ -0,0 +1,124 @@
+// Package resultcache keeps recent measurement histograms so repeated
+// executions of the same circuit can be answered without simulating again.
+// Entries are evicted least recently used first and expire after a TTL.
+package resultcache
+
+import (
+	"container/list"
+	"maps"
+	"sync"
+	"time"
+)
+
+type (
+	// Key identifies an execution. Hash is the canonical circuit hash (see
+	// circuit.Hash).
+	Key struct {
+		Hash    string
+		Backend string
+		Shots   int
+		Seed    int64
+	}
+
+	Options struct {
+		// Size is the maximum number of entries (0 = 256).
+		Size int
+		// TTL is how long an entry stays valid (0 = 10m).
+		TTL time.Duration
+	}
+
+	// Stats are the counters of a cache.
+	Stats struct {
+		Hits    int64 `json:"hits"`
+		Misses  int64 `json:"misses"`
+		Entries int   `json:"entries"`
+		Size    int   `json:"size"`
+	}
+
+	// Cache is a fixed-size LRU cache of histograms, safe for concurrent use.
+	Cache struct {
+		size int
+		ttl  time.Duration
+		now  func() time.Time
+
+		mu      sync.Mutex
+		lru     *list.List // front = most recently used
+		entries map[Key]*list.Element
+		hits    int64
+		misses  int64
+	}
+
+	entry struct {
+		key     Key
+		hist    map[string]int
+		expires time.Time
+	}
+)
+
+// New creates an empty cache.
+func New(options Options) *Cache {
+	size := options.Size
+	if size <= 0 {
+		size = 256
+	}
+	ttl := options.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &Cache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[Key]*list.Element),
+	}
+}
+
+// Get returns a copy of the cached histogram of key and counts a hit or a
+// miss.
+func (c *Cache) Get(key Key) (map[string]int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if ok && c.now().After(el.Value.(*entry).expires) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(el)
+	return maps.Clone(el.Value.(*entry).hist), true
+}
+
+// Put stores a copy of hist under key, evicting the least recently used
+// entry when the cache is full.
+func (c *Cache) Put(key Key, hist map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &entry{key: key, hist: maps.Clone(hist), expires: c.now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: c.lru.Len(), Size: c.size}
+}
+
+// remove drops an entry; c.mu must be held.
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
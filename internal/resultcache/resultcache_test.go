@@ -0,0 +1,59 @@
+package resultcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New(Options{Size: 2, TTL: time.Minute})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	bell := Key{Hash: "bell", Backend: "qsim", Shots: 100}
+	_, ok := c.Get(bell)
+	assert.False(ok)
+
+	c.Put(bell, map[string]int{"00": 50, "11": 50})
+	hist, ok := c.Get(bell)
+	assert.True(ok)
+	assert.Equal(map[string]int{"00": 50, "11": 50}, hist)
+	hist["00"] = 0
+	hist, _ = c.Get(bell)
+	assert.Equal(50, hist["00"], "callers get copies")
+
+	// Every field of the key matters.
+	for _, k := range []Key{
+		{Hash: "bell", Backend: "itsu", Shots: 100},
+		{Hash: "bell", Backend: "qsim", Shots: 10},
+		{Hash: "bell", Backend: "qsim", Shots: 100, Seed: 1},
+	} {
+		_, ok := c.Get(k)
+		assert.False(ok, "%+v", k)
+	}
+
+	// LRU eviction: bell was used last, so ghz goes first.
+	ghz := Key{Hash: "ghz", Backend: "qsim", Shots: 100}
+	c.Put(ghz, map[string]int{"000": 100})
+	c.Get(bell)
+	c.Put(Key{Hash: "grover"}, map[string]int{"11": 100})
+	_, ok = c.Get(ghz)
+	assert.False(ok, "least recently used entry is evicted")
+	_, ok = c.Get(bell)
+	assert.True(ok)
+
+	// TTL expiry.
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get(bell)
+	assert.False(ok, "expired entry")
+
+	s := c.Stats()
+	assert.Equal(int64(4), s.Hits)
+	assert.Equal(int64(6), s.Misses)
+	assert.Equal(1, s.Entries)
+	assert.Equal(2, s.Size)
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	// Quota bounds the work a single request may ask for. Zero fields are
+	// unlimited (beyond the limits of the endpoint itself).
+	Quota struct {
+		MaxShots  int
+		MaxQubits int
+	}
+
+	// APIKey is a credential accepted by the auth middleware.
+	APIKey struct {
+		Key  string
+		Name string // used in logs and as the rate limit bucket, never the key itself
+		// RateLimit overrides AuthOptions.RateLimit for this key (0 = default).
+		RateLimit int
+		// Quota overrides the non-zero fields of AuthOptions.Quota.
+		Quota Quota
+	}
+
+	AuthOptions struct {
+		// Keys are the accepted credentials; without keys every request is
+		// let through and rate limited by client IP.
+		Keys []APIKey
+		// RateLimit is the sustained number of requests per minute and client
+		// (0 = unlimited).
+		RateLimit int
+		// Burst is the number of requests a client may send at once (0 = RateLimit/10, at least 1).
+		Burst int
+		// Quota is the default quota of every client.
+		Quota Quota
+		// Public lists path prefixes that need no key, e.g. "/health".
+		Public []string
+	}
+
+	// bucket is a token bucket refilled continuously.
+	bucket struct {
+		tokens float64
+		rate   float64 // tokens per second
+		last   time.Time
+	}
+
+	// limiter keeps one token bucket per client.
+	limiter struct {
+		burst float64
+		now   func() time.Time
+
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		calls   int
+	}
+)
+
+// Context keys set by the auth middleware.
+const (
+	apiKeyNameKey = "apikey"
+	quotaKey      = "quota"
+)
+
+// auth is a middleware that authenticates requests with an API key, sent as
+// "Authorization: Bearer <key>" or "X-API-Key: <key>", and rate limits them
+// per key. Missing or unknown keys get 401, exhausted clients 429. The quota
+// of the client is stored in the context for the handlers (see QuotaFrom).
+func auth(options AuthOptions) gin.HandlerFunc {
+	lim := newLimiter(options.Burst, options.RateLimit)
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, p := range options.Public {
+			if path == p || (p != "/" && strings.HasPrefix(path, p+"/")) {
+				c.Next()
+				return
+			}
+		}
+
+		client := "ip:" + c.ClientIP()
+		rateLimit, quota := options.RateLimit, options.Quota
+		if len(options.Keys) > 0 {
+			key := findKey(options.Keys, requestKey(c.Request))
+			if key == nil {
+				c.Header("WWW-Authenticate", `Bearer realm="qplay"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid API key"})
+				return
+			}
+			client = "key:" + key.Name
+			if key.RateLimit > 0 {
+				rateLimit = key.RateLimit
+			}
+			if key.Quota.MaxShots > 0 {
+				quota.MaxShots = key.Quota.MaxShots
+			}
+			if key.Quota.MaxQubits > 0 {
+				quota.MaxQubits = key.Quota.MaxQubits
+			}
+			c.Set(apiKeyNameKey, key.Name)
+		}
+
+		if rateLimit > 0 {
+			if wait := lim.take(client, float64(rateLimit)/60); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+				return
+			}
+		}
+		c.Set(quotaKey, quota)
+		c.Next()
+	}
+}
+
+// QuotaFrom returns the quota the auth middleware assigned to the request.
+func QuotaFrom(c *gin.Context) Quota {
+	q, _ := c.Get(quotaKey)
+	quota, _ := q.(Quota)
+	return quota
+}
+
+// APIKeyName returns the name of the key that authenticated the request, or
+// "" when authentication is disabled.
+func APIKeyName(c *gin.Context) string {
+	return c.GetString(apiKeyNameKey)
+}
+
+// requestKey extracts the API key of a request.
+func requestKey(r *http.Request) string {
+	if k := r.Header.Get("X-API-Key"); k != "" {
+		return k
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// findKey compares the given key with every accepted key in constant time.
+func findKey(keys []APIKey, given string) *APIKey {
+	if given == "" {
+		return nil
+	}
+	var found *APIKey
+	for i := range keys {
+		if subtle.ConstantTimeCompare([]byte(keys[i].Key), []byte(given)) == 1 {
+			found = &keys[i]
+		}
+	}
+	return found
+}
+
+func newLimiter(burst, ratePerMinute int) *limiter {
+	if burst <= 0 {
+		burst = max(1, ratePerMinute/10)
+	}
+	return &limiter{burst: float64(burst), now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// take removes a token from the bucket of client, which refills at rate
+// tokens per second. It returns 0 on success and otherwise how long until
+// the next token is available.
+func (l *limiter) take(client string, rate float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.calls++
+	if l.calls%1024 == 0 {
+		l.prune(now)
+	}
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.rate = rate
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	b.tokens--
+	return 0
+}
+
+// prune forgets buckets that have refilled completely; l.mu must be held.
+func (l *limiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last).Seconds()*b.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthEngine(options AuthOptions) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(auth(options))
+	engine.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+	engine.GET("/api/quota", func(c *gin.Context) {
+		q := QuotaFrom(c)
+		c.JSON(http.StatusOK, gin.H{"key": APIKeyName(c), "shots": q.MaxShots, "qubits": q.MaxQubits})
+	})
+	return engine
+}
+
+func doAuthRequest(engine *gin.Engine, path string, header ...string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuth_Keys(t *testing.T) {
+	assert := assert.New(t)
+	engine := newAuthEngine(AuthOptions{
+		Keys: []APIKey{
+			{Key: "student-secret", Name: "student"},
+			{Key: "teacher-secret", Name: "teacher", Quota: Quota{MaxShots: 100000}},
+		},
+		Quota:  Quota{MaxShots: 1000, MaxQubits: 5},
+		Public: []string{"/health"},
+	})
+
+	rec := doAuthRequest(engine, "/health")
+	assert.Equal(http.StatusOK, rec.Code, "public paths need no key")
+
+	rec = doAuthRequest(engine, "/api/quota")
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+	assert.Contains(rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+	rec = doAuthRequest(engine, "/api/quota", "Authorization", "Bearer nope")
+	assert.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = doAuthRequest(engine, "/api/quota", "Authorization", "Bearer student-secret")
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.JSONEq(`{"key":"student","shots":1000,"qubits":5}`, rec.Body.String())
+
+	rec = doAuthRequest(engine, "/api/quota", "X-API-Key", "teacher-secret")
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.JSONEq(`{"key":"teacher","shots":100000,"qubits":5}`, rec.Body.String(), "per-key quotas override the defaults")
+}
+
+func TestAuth_RateLimit(t *testing.T) {
+	assert := assert.New(t)
+	engine := newAuthEngine(AuthOptions{
+		Keys: []APIKey{
+			{Key: "a", Name: "a"},
+			{Key: "b", Name: "b", RateLimit: 600},
+		},
+		RateLimit: 60,
+		Burst:     2,
+	})
+
+	for range 2 {
+		assert.Equal(http.StatusOK, doAuthRequest(engine, "/api/quota", "X-API-Key", "a").Code)
+	}
+	rec := doAuthRequest(engine, "/api/quota", "X-API-Key", "a")
+	assert.Equal(http.StatusTooManyRequests, rec.Code)
+	assert.Equal("1", rec.Header().Get("Retry-After"))
+
+	assert.Equal(http.StatusOK, doAuthRequest(engine, "/api/quota", "X-API-Key", "b").Code, "clients have separate buckets")
+}
+
+func TestAuth_NoKeys(t *testing.T) {
+	engine := newAuthEngine(AuthOptions{RateLimit: 60, Burst: 1})
+	assert.Equal(t, http.StatusOK, doAuthRequest(engine, "/api/quota").Code, "without keys requests are let through")
+	assert.Equal(t, http.StatusTooManyRequests, doAuthRequest(engine, "/api/quota").Code, "but still rate limited by IP")
+}
+
+func TestLimiter(t *testing.T) {
+	assert := assert.New(t)
+	l := newLimiter(2, 0)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	assert.Zero(l.take("x", 1))
+	assert.Zero(l.take("x", 1))
+	assert.Equal(time.Second, l.take("x", 1))
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(500*time.Millisecond, l.take("x", 1))
+	now = now.Add(500 * time.Millisecond)
+	assert.Zero(l.take("x", 1), "one token refilled")
+
+	now = now.Add(time.Hour)
+	l.prune(now)
+	assert.Empty(l.buckets, "refilled buckets are forgotten")
+}
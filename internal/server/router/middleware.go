@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/internal/metrics"
 )
 
 var (
@@ -47,7 +48,8 @@ func cors(options CORSOptions) gin.HandlerFunc {
 // It injects the logger into the context.
 // It is used to log the request and response.
 // It is used to set the request id and request count in the context.
-func requestWrapper(log *logger.Logger) func(c *gin.Context) {
+// When m is not nil, it also counts the request and records its latency.
+func requestWrapper(log *logger.Logger, m *httpMetrics) func(c *gin.Context) {
 	return func(c *gin.Context) {
 		reqCount, reqID := setupContext(c)
 		l := log.SpawnForContext(reqCount, reqID)
@@ -61,6 +63,7 @@ func requestWrapper(log *logger.Logger) func(c *gin.Context) {
 
 		status := c.Writer.Status()
 		latency := time.Since(start)
+		m.observe(c, status, latency)
 
 		meta := []interface{}{
 			"path", reqPath,
@@ -80,6 +83,37 @@ func requestWrapper(log *logger.Logger) func(c *gin.Context) {
 	}
 }
 
+// httpMetrics are the request metrics recorded by requestWrapper.
+type httpMetrics struct {
+	requests *metrics.CounterVec
+	latency  *metrics.HistogramVec
+}
+
+func newHTTPMetrics(reg *metrics.Registry) *httpMetrics {
+	if reg == nil {
+		return nil
+	}
+	return &httpMetrics{
+		requests: reg.Counter("qplay_http_requests_total", "HTTP requests served, by route, method and status code.", "route", "method", "status"),
+		latency:  reg.Histogram("qplay_http_request_duration_seconds", "HTTP request latency in seconds, by route, method and status code.", metrics.DefBuckets, "route", "method", "status"),
+	}
+}
+
+// observe records one request. Routes are reported by their pattern so the
+// label stays bounded; unmatched paths share one label.
+func (m *httpMetrics) observe(c *gin.Context, status int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	m.requests.With(route, c.Request.Method, code).Inc()
+	m.latency.With(route, c.Request.Method, code).Observe(latency.Seconds())
+}
+
 // setupContext sets up the context for the request.
 // It sets the request id and increments the request count.
 func setupContext(c *gin.Context) (reqCount string, reqID string) {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/internal/metrics"
 )
 
 type (
@@ -22,6 +23,11 @@ type (
 		Logger          *logger.Logger
 		BasePath        string
 		CORSAllowOrigin string
+		Auth            AuthOptions
+		// Metrics receives the request metrics when not nil.
+		Metrics *metrics.Registry
+		// StaticDir is served under /static ("" = "./public").
+		StaticDir string
 	}
 
 	Route struct {
@@ -43,14 +49,19 @@ func NewRouter(options RouterOptions) *Router {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 
-	engine.Static("/static", "./public") //TODO it should be configurable
+	staticDir := options.StaticDir
+	if staticDir == "" {
+		staticDir = "./public"
+	}
+	engine.Static("/static", staticDir)
 
 	engine.Use(gin.Recovery())
-	engine.Use(requestWrapper(options.Logger))
+	engine.Use(requestWrapper(options.Logger, newHTTPMetrics(options.Metrics)))
 
 	engine.Use(cors(CORSOptions{
 		Origin: options.CORSAllowOrigin,
 	}))
+	engine.Use(auth(options.Auth))
 
 	router := &Router{
 		Engine:   engine,
@@ -4,12 +4,17 @@ import (
 	"context"
 
 	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/internal/metrics"
 	"github.com/kegliz/qplay/internal/server/router"
 )
 
 type (
 	EngineOptions struct {
-		Debug bool
+		Debug   bool
+		Auth    router.AuthOptions
+		Metrics *metrics.Registry
+		// StaticDir is served under /static ("" = "./public").
+		StaticDir string
 	}
 
 	Server interface {
@@ -23,7 +28,10 @@ func NewLoggerAndRouter(options EngineOptions) (l *logger.Logger, r *router.Rout
 		Debug: options.Debug,
 	})
 	r = router.NewRouter(router.RouterOptions{
-		Logger: l,
+		Logger:    l,
+		Auth:      options.Auth,
+		Metrics:   options.Metrics,
+		StaticDir: options.StaticDir,
 	})
 	return
 }
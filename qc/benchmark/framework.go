@@ -30,6 +30,15 @@ var DefaultResourceLimits = ResourceLimits{
 	MaxQubits:       5,  // Conservative qubit limit
 }
 
+// LargeStateResourceLimits allow the 20+ qubit states that exercise
+// multi-threaded amplitude kernels; a 24-qubit state takes 256MB
+var LargeStateResourceLimits = ResourceLimits{
+	MaxMemoryMB:     2048,
+	MaxDuration:     5 * time.Minute,
+	MaxCircuitDepth: 20,
+	MaxQubits:       24,
+}
+
 // BenchmarkScenario represents different types of benchmark tests
 type BenchmarkScenario string
 
@@ -47,7 +56,8 @@ type BenchmarkConfig struct {
 	Scenario    BenchmarkScenario
 	Config      testutil.TestConfig
 	RunnerName  string
-	Limits      ResourceLimits // Resource limits for safe execution
+	Limits      ResourceLimits         // Resource limits for safe execution
+	Options     map[string]interface{} // Passed to Configure if the runner supports it
 }
 
 // ResourceUsage tracks resource consumption during benchmarks
@@ -215,6 +225,12 @@ func RunSingleBenchmark(b *testing.B, config BenchmarkConfig) BenchmarkResult {
 	// Configure the runner if it supports configuration
 	if configurable, ok := runner.(simulator.ConfigurableRunner); ok {
 		configurable.SetVerbose(false) // Disable verbose for benchmarking
+		if len(config.Options) > 0 {
+			if err := configurable.Configure(config.Options); err != nil {
+				result.Error = fmt.Sprintf("failed to configure runner: %v", err)
+				return result
+			}
+		}
 	}
 
 	// Build the circuit
@@ -0,0 +1,47 @@
+package benchmark
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	_ "github.com/kegliz/qplay/qc/simulator/qsim" // Import to register the runner
+	"github.com/kegliz/qplay/qc/testutil"
+)
+
+// BenchmarkLargeStates runs qsim on 20+ qubit states, serially and with its
+// amplitude kernels split across all cores, to show the intra-state speedup
+func BenchmarkLargeStates(b *testing.B) {
+	threadCounts := []int{1}
+	if n := runtime.GOMAXPROCS(0); n > 1 {
+		threadCounts = append(threadCounts, n)
+	}
+
+	for _, qubits := range []int{20, 22, 24} {
+		for _, circuitType := range []CircuitType{SuperpositionCircuit, MixedGatesCircuit} {
+			for _, threads := range threadCounts {
+				name := fmt.Sprintf("%dq/%s/%dthreads", qubits, circuitType, threads)
+				b.Run(name, func(b *testing.B) {
+					config := BenchmarkConfig{
+						CircuitType: circuitType,
+						Scenario:    SerialExecution,
+						Config: testutil.TestConfig{
+							Shots:   4,
+							Qubits:  qubits,
+							Workers: 1,
+							Timeout: LargeStateResourceLimits.MaxDuration,
+						},
+						RunnerName: "qsim",
+						Limits:     LargeStateResourceLimits,
+						Options:    map[string]interface{}{"threads": threads},
+					}
+
+					result := RunSingleBenchmark(b, config)
+					if !result.Success {
+						b.Fatalf("Benchmark failed: %s", result.Error)
+					}
+				})
+			}
+		}
+	}
+}
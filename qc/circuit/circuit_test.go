@@ -270,3 +270,31 @@ func TestYGateIntegration(t *testing.T) {
 	assert.Equal(3, directCircuit.Qubits(), "Direct circuit qubit count mismatch")
 	assert.Equal(0, directCircuit.Clbits(), "Direct circuit classical bit count mismatch")
 }
+
+func TestHash(t *testing.T) {
+	build := func(f func(b builder.Builder)) circuit.Circuit {
+		b := builder.New(builder.Q(3), builder.C(3))
+		f(b)
+		c, err := b.BuildCircuit()
+		require.NoError(t, err)
+		return c
+	}
+
+	bell := build(func(b builder.Builder) { b.H(0).X(2).CNOT(0, 1).Measure(0, 0).Measure(1, 1) })
+	assert.Len(t, circuit.Hash(bell), 64)
+
+	// Same circuit, rebuilt with fresh node IDs and the independent ops
+	// added in a different order.
+	reordered := build(func(b builder.Builder) { b.X(2).H(0).CNOT(0, 1).Measure(1, 1).Measure(0, 0) })
+	assert.Equal(t, circuit.Hash(bell), circuit.Hash(reordered))
+
+	different := []circuit.Circuit{
+		build(func(b builder.Builder) { b.H(0).X(2).CNOT(1, 0).Measure(0, 0).Measure(1, 1) }),
+		build(func(b builder.Builder) { b.H(0).X(2).CNOT(0, 1).Measure(0, 1).Measure(1, 0) }),
+		build(func(b builder.Builder) { b.H(0).CNOT(0, 1).X(0).Measure(0, 0).Measure(1, 1) }),
+		build(func(b builder.Builder) { b.H(0).X(2).CNOT(0, 1).Measure(0, 0) }),
+	}
+	for i, c := range different {
+		assert.NotEqual(t, circuit.Hash(bell), circuit.Hash(c), "circuit %d", i)
+	}
+}
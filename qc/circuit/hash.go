@@ -0,0 +1,41 @@
+package circuit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hash returns a canonical fingerprint of a circuit as a hex SHA-256 digest.
+//
+// Two circuits hash alike when they apply the same gates to the same qubits
+// in the same dependency order: the DAG node IDs play no part, and ops that
+// act on disjoint qubits may have been added in any order, since each op is
+// identified by its layout column and primary line rather than by its
+// position in the build.
+func Hash(c Circuit) string {
+	ops := c.Operations()
+	sort.SliceStable(ops, func(i, j int) bool {
+		if ops[i].TimeStep != ops[j].TimeStep {
+			return ops[i].TimeStep < ops[j].TimeStep
+		}
+		return ops[i].Line < ops[j].Line
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "q%d c%d\n", c.Qubits(), c.Clbits())
+	for _, op := range ops {
+		fmt.Fprintf(&sb, "%d %s", op.TimeStep, op.G.Name())
+		for _, q := range op.Qubits {
+			fmt.Fprintf(&sb, " %d", q)
+		}
+		if op.Cbit >= 0 {
+			fmt.Fprintf(&sb, " ->%d", op.Cbit)
+		}
+		sb.WriteByte('\n')
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
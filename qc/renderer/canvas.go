@@ -0,0 +1,209 @@
+package renderer
+
+import (
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// ─── canvas ───────────────────────────────────────────────────────────────
+// canvas is the handful of drawing primitives the plots need. The PNG
+// backend draws with gg, the SVG backend writes markup, so every plot lays
+// itself out once and can be exported in both formats.
+
+type canvas interface {
+	rect(x, y, w, h float64, fill, stroke color.Color)
+	line(x1, y1, x2, y2 float64, c color.Color, dashed bool)
+	circle(x, y, r float64, fill, stroke color.Color)
+	ellipse(x, y, rx, ry float64, stroke color.Color, dashed bool)
+	// text draws s anchored like gg.DrawStringAnchored: ax, ay in [0,1]
+	// select the anchor point of the text box.
+	text(s string, x, y, ax, ay float64, c color.Color)
+	// vtext draws s rotated by -90°, its end anchored at (x, y).
+	vtext(s string, x, y float64, c color.Color)
+}
+
+// ggCanvas draws on a gg context.
+type ggCanvas struct {
+	dc *gg.Context
+	lw float64
+}
+
+func newGGCanvas(w, h int, s Style) (*ggCanvas, error) {
+	dc := gg.NewContext(w, h)
+	dc.SetColor(s.Background)
+	dc.Clear()
+	face, err := s.fontFace()
+	if err != nil {
+		return nil, fmt.Errorf("renderer: loading font: %w", err)
+	}
+	dc.SetFontFace(face)
+	return &ggCanvas{dc: dc, lw: s.LineWidth * s.Scale}, nil
+}
+
+func (g *ggCanvas) rect(x, y, w, h float64, fill, stroke color.Color) {
+	g.dc.DrawRectangle(x, y, w, h)
+	g.paint(fill, stroke)
+}
+
+func (g *ggCanvas) line(x1, y1, x2, y2 float64, c color.Color, dashed bool) {
+	g.dc.SetColor(c)
+	g.dc.SetLineWidth(g.lw)
+	if dashed {
+		g.dc.SetDash(4*g.lw, 3*g.lw)
+	}
+	g.dc.DrawLine(x1, y1, x2, y2)
+	g.dc.Stroke()
+	g.dc.SetDash()
+}
+
+func (g *ggCanvas) circle(x, y, r float64, fill, stroke color.Color) {
+	g.dc.DrawCircle(x, y, r)
+	g.paint(fill, stroke)
+}
+
+func (g *ggCanvas) ellipse(x, y, rx, ry float64, stroke color.Color, dashed bool) {
+	if dashed {
+		g.dc.SetDash(4*g.lw, 3*g.lw)
+	}
+	g.dc.DrawEllipse(x, y, rx, ry)
+	g.paint(nil, stroke)
+	g.dc.SetDash()
+}
+
+func (g *ggCanvas) text(s string, x, y, ax, ay float64, c color.Color) {
+	g.dc.SetColor(c)
+	g.dc.DrawStringAnchored(s, x, y, ax, ay)
+}
+
+func (g *ggCanvas) vtext(s string, x, y float64, c color.Color) {
+	g.dc.Push()
+	g.dc.RotateAbout(-math.Pi/2, x, y)
+	g.text(s, x, y, 1, 0.5, c)
+	g.dc.Pop()
+}
+
+// paint fills and/or strokes the current path; nil colors are skipped.
+func (g *ggCanvas) paint(fill, stroke color.Color) {
+	if fill != nil {
+		g.dc.SetColor(fill)
+		if stroke != nil {
+			g.dc.FillPreserve()
+		} else {
+			g.dc.Fill()
+		}
+	}
+	if stroke != nil {
+		g.dc.SetColor(stroke)
+		g.dc.SetLineWidth(g.lw)
+		g.dc.Stroke()
+	}
+	g.dc.ClearPath()
+}
+
+// svgCanvas accumulates SVG elements.
+type svgCanvas struct {
+	sb       strings.Builder
+	w, h     int
+	lw       float64
+	fontSize float64
+}
+
+func newSVGCanvas(w, h int, s Style) *svgCanvas {
+	c := &svgCanvas{w: w, h: h, lw: s.LineWidth * s.Scale, fontSize: s.FontSize * s.Scale}
+	fmt.Fprintf(&c.sb, `<rect width="%d" height="%d" fill="%s"/>`+"\n", w, h, svgColor(s.Background))
+	return c
+}
+
+// String returns the complete SVG document.
+func (c *svgCanvas) String() string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%s">`+"\n%s</svg>\n",
+		c.w, c.h, c.w, c.h, svgNum(c.fontSize), c.sb.String())
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill, stroke color.Color) {
+	fmt.Fprintf(&c.sb, `<rect x="%s" y="%s" width="%s" height="%s"%s/>`+"\n",
+		svgNum(x), svgNum(y), svgNum(w), svgNum(h), c.paint(fill, stroke, false))
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2 float64, col color.Color, dashed bool) {
+	fmt.Fprintf(&c.sb, `<line x1="%s" y1="%s" x2="%s" y2="%s"%s/>`+"\n",
+		svgNum(x1), svgNum(y1), svgNum(x2), svgNum(y2), c.paint(nil, col, dashed))
+}
+
+func (c *svgCanvas) circle(x, y, r float64, fill, stroke color.Color) {
+	fmt.Fprintf(&c.sb, `<circle cx="%s" cy="%s" r="%s"%s/>`+"\n",
+		svgNum(x), svgNum(y), svgNum(r), c.paint(fill, stroke, false))
+}
+
+func (c *svgCanvas) ellipse(x, y, rx, ry float64, stroke color.Color, dashed bool) {
+	fmt.Fprintf(&c.sb, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`+"\n",
+		svgNum(x), svgNum(y), svgNum(rx), svgNum(ry), c.paint(nil, stroke, dashed))
+}
+
+func (c *svgCanvas) text(s string, x, y, ax, ay float64, col color.Color) {
+	fmt.Fprintf(&c.sb, `<text x="%s" y="%s"%s fill="%s">%s</text>`+"\n",
+		svgNum(x), svgNum(y), svgAnchor(ax, ay), svgColor(col), html.EscapeString(s))
+}
+
+func (c *svgCanvas) vtext(s string, x, y float64, col color.Color) {
+	fmt.Fprintf(&c.sb, `<text x="%s" y="%s" transform="rotate(-90 %s %s)"%s fill="%s">%s</text>`+"\n",
+		svgNum(x), svgNum(y), svgNum(x), svgNum(y), svgAnchor(1, 0.5), svgColor(col), html.EscapeString(s))
+}
+
+func (c *svgCanvas) paint(fill, stroke color.Color, dashed bool) string {
+	var sb strings.Builder
+	if fill != nil {
+		fmt.Fprintf(&sb, ` fill="%s"`, svgColor(fill))
+	} else {
+		sb.WriteString(` fill="none"`)
+	}
+	if stroke != nil {
+		fmt.Fprintf(&sb, ` stroke="%s" stroke-width="%s"`, svgColor(stroke), svgNum(c.lw))
+		if dashed {
+			fmt.Fprintf(&sb, ` stroke-dasharray="%s %s"`, svgNum(4*c.lw), svgNum(3*c.lw))
+		}
+	}
+	return sb.String()
+}
+
+// svgAnchor translates gg-style anchors into SVG text attributes.
+func svgAnchor(ax, ay float64) string {
+	anchor, baseline := "start", "auto"
+	switch {
+	case ax >= 0.75:
+		anchor = "end"
+	case ax >= 0.25:
+		anchor = "middle"
+	}
+	switch {
+	case ay >= 0.75:
+		baseline = "hanging"
+	case ay >= 0.25:
+		baseline = "central"
+	}
+	return fmt.Sprintf(` text-anchor="%s" dominant-baseline="%s"`, anchor, baseline)
+}
+
+// svgColor formats a color as #rrggbb, adding an opacity when needed.
+func svgColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+// svgNum prints a coordinate with at most two decimals.
+func svgNum(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -16,19 +16,31 @@ import (
 // GGPNG is a renderer that uses the gg library to create PNG images of quantum circuits.
 // It draws the circuit operations and wires based on the provided circuit data.
 
-type GGPNG struct{ Cell float64 }
+type GGPNG struct {
+	Cell  float64
+	Style Style
+}
 
-// NewRenderer returns a renderer that emits lossless PNGs using gg.
-func NewRenderer(cellPx int) GGPNG { return GGPNG{Cell: float64(cellPx)} }
+// NewRenderer returns a renderer that emits lossless PNGs using gg. Without
+// options it uses the light theme.
+func NewRenderer(cellPx int, opts ...Option) GGPNG {
+	r := GGPNG{Cell: float64(cellPx), Style: LightStyle()}
+	for _, o := range opts {
+		o(&r)
+	}
+	return r
+}
 
 func (r GGPNG) Render(c circuit.Circuit) (image.Image, error) {
+	r = r.resolved()
+
 	// Ensure minimum width for drawing wires even if circuit is empty (MaxStep = -1)
 	steps := c.MaxStep() + 1
 	if steps < 1 {
 		steps = 1 // Minimum 1 step width to show wires
 	}
-	w := int(float64(steps) * r.Cell)
-	h := int(float64(c.Qubits()) * r.Cell)
+	w := int(r.margin() + float64(steps)*r.pitch())
+	h := int(float64(c.Qubits())*r.Cell + float64(c.Clbits())*r.clbitPitch())
 
 	// Handle edge cases
 	if h <= 0 {
@@ -36,20 +48,39 @@ func (r GGPNG) Render(c circuit.Circuit) (image.Image, error) {
 	}
 
 	dc := gg.NewContext(w, h)
-	dc.SetRGB(1, 1, 1) // white background
+	dc.SetColor(r.Style.Background)
 	dc.Clear()
+	face, err := r.Style.fontFace()
+	if err != nil {
+		return nil, fmt.Errorf("renderer: loading font: %w", err)
+	}
+	dc.SetFontFace(face)
 
 	// — wires
-	dc.SetRGB(0, 0, 0)
-	dc.SetLineWidth(1) // Set default line width
+	dc.SetLineWidth(r.lineWidth()) // Set default line width
 	for i := 0; i < c.Qubits(); i++ {
 		y := r.y(i)
-		dc.DrawLine(0, y, float64(w), y)
+		dc.SetColor(r.Style.Label)
+		dc.DrawStringAnchored(fmt.Sprintf("q%d", i), r.margin()*0.8, y, 1, 0.5)
+		dc.SetColor(r.Style.Wire)
+		dc.DrawLine(r.margin(), y, float64(w), y)
 		dc.Stroke()
 	}
 
+	// — classical register band (double lines below the qubits)
+	for i := 0; i < c.Clbits(); i++ {
+		y := r.cy(c, i)
+		dc.SetColor(r.Style.Label)
+		dc.DrawStringAnchored(fmt.Sprintf("c%d", i), r.margin()*0.8, y, 1, 0.5)
+		dc.SetColor(r.Style.Classical)
+		r.drawDoubleLine(dc, r.margin(), y, float64(w), y)
+	}
+
 	// Process operations using calculated TimeStep and Line
 	for _, op := range c.Operations() {
+		// Every gate draws with its family colors
+		dc.SetColor(r.Style.gate(op.G.Name()).Stroke)
+
 		// Handle standard single-qubit box gates first
 		switch op.G.Name() {
 		case "H", "X", "Y", "Z", "S":
@@ -72,6 +103,7 @@ func (r GGPNG) Render(c circuit.Circuit) (image.Image, error) {
 		case "TOFFOLI":
 			r.drawToffoli(dc, op)
 		case "MEASURE":
+			r.drawMeasurementLink(dc, c, op)
 			r.drawMeasurement(dc, op)
 		default:
 			// Attempt to draw any other unrecognized single-qubit gate as a box
@@ -102,23 +134,77 @@ func (r GGPNG) Save(path string, c circuit.Circuit) error { // Changed CircuitSt
 
 // ─── helpers ──────────────────────────────────────────────────────────────
 
-func (r GGPNG) x(step int) float64 { return float64(step)*r.Cell + r.Cell/2 }
+// resolved completes the style and folds its DPI scale into the cell size.
+func (r GGPNG) resolved() GGPNG {
+	r.Style = r.Style.withDefaults()
+	r.Cell *= r.Style.Scale
+	return r
+}
+
+func (r GGPNG) x(step int) float64 { return r.margin() + float64(step)*r.pitch() + r.pitch()/2 }
 func (r GGPNG) y(line int) float64 { return float64(line)*r.Cell + r.Cell/2 }
 
+// cy returns the vertical position of a classical bit wire.
+func (r GGPNG) cy(c circuit.Circuit, cbit int) float64 {
+	return float64(c.Qubits())*r.Cell + (float64(cbit)+0.5)*r.clbitPitch()
+}
+
+// pitch is the horizontal distance between time steps.
+func (r GGPNG) pitch() float64 {
+	if r.Style.Compact {
+		return r.Cell * 0.75
+	}
+	return r.Cell
+}
+
+// margin is the width of the left band holding the register labels.
+func (r GGPNG) margin() float64 {
+	if r.Style.Compact {
+		return r.Cell * 0.45
+	}
+	return r.Cell * 0.6
+}
+
+// clbitPitch is the vertical distance between classical wires.
+func (r GGPNG) clbitPitch() float64 {
+	if r.Style.Compact {
+		return r.Cell * 0.35
+	}
+	return r.Cell / 2
+}
+
+// lineWidth is the scaled stroke width.
+func (r GGPNG) lineWidth() float64 { return r.Style.LineWidth * r.Style.Scale }
+
+// drawDoubleLine draws a classical (double) wire between two points.
+func (r GGPNG) drawDoubleLine(dc *gg.Context, x1, y1, x2, y2 float64) {
+	d := r.Cell * 0.04
+	if x1 == x2 { // vertical
+		dc.DrawLine(x1-d, y1, x2-d, y2)
+		dc.DrawLine(x1+d, y1, x2+d, y2)
+	} else { // horizontal
+		dc.DrawLine(x1, y1-d, x2, y2-d)
+		dc.DrawLine(x1, y1+d, x2, y2+d)
+	}
+	dc.Stroke()
+}
+
 func (r GGPNG) drawBoxGate(dc *gg.Context, op circuit.Operation) {
 	// Assumes op.Line is the target qubit for single-qubit gates
 	if op.Line < 0 {
 		return
 	} // Skip if no line associated
 	x, y := r.x(op.TimeStep), r.y(op.Line)
-	size := r.Cell * .7
+	size := math.Min(r.Cell, r.pitch()) * (1 - 2*(*r.Style.Padding))
+	colors := r.Style.gate(op.G.Name())
 	dc.DrawRectangle(x-size/2, y-size/2, size, size)
-	dc.SetRGB(1, 1, 1) // White fill
+	dc.SetColor(colors.Fill)
 	dc.FillPreserve()
-	dc.SetRGB(0, 0, 0) // Black stroke
-	dc.SetLineWidth(1) // Ensure consistent line width
+	dc.SetColor(colors.Stroke)
+	dc.SetLineWidth(r.lineWidth()) // Ensure consistent line width
 	dc.Stroke()
 	// Use DrawSymbol() for the text
+	dc.SetColor(colors.Text)
 	dc.DrawStringAnchored(op.G.DrawSymbol(), x, y, 0.5, 0.5)
 }
 
@@ -148,7 +234,6 @@ func (r GGPNG) drawToffoli(dc *gg.Context, op circuit.Operation) {
 
 	x := r.x(col)
 	// Draw controls (●)
-	dc.SetRGB(0, 0, 0)
 	dc.DrawCircle(x, r.y(ctrl1Line), r.Cell*0.12)
 	dc.Fill()
 	dc.DrawCircle(x, r.y(ctrl2Line), r.Cell*0.12)
@@ -177,17 +262,43 @@ func (r GGPNG) drawMeasurement(dc *gg.Context, op circuit.Operation) {
 	}
 	x, y := r.x(op.TimeStep), r.y(op.Line)
 	rad := r.Cell * 0.25
-	dc.SetRGB(0, 0, 0)
+	colors := r.Style.gate(op.G.Name())
 	dc.NewSubPath()
 	dc.DrawArc(x, y, rad, math.Pi, 2*math.Pi)
 	dc.ClosePath()
+	dc.SetColor(colors.Fill)
+	dc.FillPreserve()
+	dc.SetColor(colors.Stroke)
 	dc.Stroke() // Draw the arc border
 	// Draw the needle
 	dc.MoveTo(x, y)
 	dc.LineTo(x+rad*0.8, y-rad*0.8)
 	dc.Stroke()
-	// Draw the label "M"
-	dc.DrawStringAnchored("M", x+rad*1.6, y-rad*0.4, 0.0, 0.5)
+	// Draw the label: the target classical bit, or "M" if there is none
+	label := "M"
+	if op.Cbit >= 0 {
+		label = fmt.Sprintf("c%d", op.Cbit)
+	}
+	dc.SetColor(colors.Text)
+	dc.DrawStringAnchored(label, x-rad*1.2, y-rad*0.6, 1, 0.5)
+}
+
+// drawMeasurementLink connects a measurement to the classical bit it writes
+// with a double line ending in an arrow head on the Operation.Cbit wire.
+func (r GGPNG) drawMeasurementLink(dc *gg.Context, c circuit.Circuit, op circuit.Operation) {
+	if op.Line < 0 || op.Cbit < 0 || op.Cbit >= c.Clbits() {
+		return
+	}
+	x, y := r.x(op.TimeStep), r.y(op.Line)
+	tip := r.cy(c, op.Cbit)
+	head := r.Cell * 0.1
+	dc.SetColor(r.Style.Classical)
+	r.drawDoubleLine(dc, x, y, x, tip-head)
+	dc.MoveTo(x-head*0.7, tip-head)
+	dc.LineTo(x+head*0.7, tip-head)
+	dc.LineTo(x, tip)
+	dc.ClosePath()
+	dc.Fill()
 }
 
 func (r GGPNG) drawCNOT(dc *gg.Context, op circuit.Operation) {
@@ -204,7 +315,6 @@ func (r GGPNG) drawCNOT(dc *gg.Context, op circuit.Operation) {
 
 	x := r.x(col)
 	// Control ●
-	dc.SetRGB(0, 0, 0)
 	dc.DrawCircle(x, r.y(controlLine), r.Cell*0.12)
 	dc.Fill()
 
@@ -241,7 +351,6 @@ func (r GGPNG) drawCZ(dc *gg.Context, op circuit.Operation) {
 	yTgt := r.y(targetLine)
 
 	// Control dot ●
-	dc.SetRGB(0, 0, 0)
 	dc.DrawCircle(x, yCtrl, r.Cell*0.12)
 	dc.Fill()
 
@@ -270,12 +379,10 @@ func (r GGPNG) drawSwap(dc *gg.Context, op circuit.Operation) {
 	y2 := r.y(q2Line)
 
 	// Draw crosses at both qubit lines
-	dc.SetRGB(0, 0, 0)
 	r.drawSwapCross(dc, x, y1)
 	r.drawSwapCross(dc, x, y2)
 
 	// Draw the vertical connecting line
-	dc.SetLineWidth(1) // Ensure consistent line width
 	dc.DrawLine(x, y1, x, y2)
 	dc.Stroke()
 }
@@ -304,7 +411,6 @@ func (r GGPNG) drawFredkin(dc *gg.Context, op circuit.Operation) {
 	x := r.x(col)
 
 	// Control ●
-	dc.SetRGB(0, 0, 0)
 	dc.DrawCircle(x, r.y(controlLine), r.Cell*0.12)
 	dc.Fill()
 
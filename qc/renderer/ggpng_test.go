@@ -1,7 +1,9 @@
 package renderer
 
 import (
+	"image"
 	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
@@ -149,3 +151,50 @@ func TestGGPNG_Save(t *testing.T) {
 	_, err = png.Decode(f2)
 	assert.NoError(err, "file %s should be a valid PNG", filePath2)
 }
+
+// inked reports whether any pixel of column x between y0 and y1 differs
+// noticeably from the white background.
+func inked(img image.Image, x, y0, y1 int) bool {
+	for y := y0; y <= y1; y++ {
+		r, g, b, _ := img.At(x, y).RGBA()
+		if r+g+b < 3*0xd000 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGGPNG_ClassicalBand(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	b := builder.New(builder.Q(2), builder.C(2))
+	b.H(0).Measure(0, 1)
+	c, err := b.BuildCircuit()
+	require.NoError(err)
+
+	r := NewRenderer(defaultCellSize)
+	img, err := r.Render(c)
+	require.NoError(err)
+
+	// Two qubit rows plus two half-height classical rows, and a label margin.
+	assert.Equal(int(2*r.Cell+2*r.clbitPitch()), img.Bounds().Dy())
+	assert.Equal(int(r.margin()+2*r.Cell), img.Bounds().Dx())
+
+	// The c1 double wire is drawn away from any gate.
+	c1 := int(math.Round(r.cy(c, 1)))
+	assert.True(inked(img, int(r.x(0)), c1-5, c1+5), "c1 wire should be drawn")
+
+	// The measurement link runs down from q0 across q1 and c0 to c1.
+	xm := int(math.Round(r.x(1) - r.Cell*0.04)) // left strand of the double line
+	assert.True(inked(img, xm, int(r.y(1))+5, int(r.y(1))+15), "measurement link should cross q1")
+	assert.True(inked(img, xm, int(r.cy(c, 0))-5, int(r.cy(c, 0))+5), "measurement link should cross c0")
+	assert.False(inked(img, xm, c1+6, img.Bounds().Dy()-1), "measurement link should stop at c1")
+
+	// Register labels sit in the left margin.
+	labelInk := false
+	for x := 0; x < int(r.margin()); x++ {
+		labelInk = labelInk || inked(img, x, int(r.y(0))-6, int(r.y(0))+6)
+	}
+	assert.True(labelInk, "q0 label should be drawn in the margin")
+}
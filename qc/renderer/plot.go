@@ -0,0 +1,516 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ─── plots ────────────────────────────────────────────────────────────────
+// Plots visualise simulation output rather than circuits: measurement
+// histograms, statevector amplitudes and per-qubit Bloch spheres. Each plot
+// renders to an image.Image (PNG) or an SVG document from the same layout,
+// and takes its colors and font from a Style. Statevector basis states are
+// written with qubit 0 first, the simulator.Clbit0First order of histogram
+// keys; histogram keys are drawn as given.
+
+// HistogramPlot draws a measurement histogram as a bar chart.
+type HistogramPlot struct {
+	Width, Height int
+	Style         Style
+	Title         string
+	// Ideal is an optional reference distribution drawn over the bars.
+	Ideal map[string]float64
+}
+
+// NewHistogramPlot returns a histogram plot of the given size in pixels.
+func NewHistogramPlot(width, height int) HistogramPlot {
+	return HistogramPlot{Width: width, Height: height, Style: LightStyle()}
+}
+
+// Render draws the histogram as an image.
+func (p HistogramPlot) Render(hist map[string]int) (image.Image, error) {
+	return renderPNG(p.Width, p.Height, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, w, h, s, hist)
+	})
+}
+
+// SVG draws the histogram as an SVG document.
+func (p HistogramPlot) SVG(hist map[string]int) (string, error) {
+	return renderSVG(p.Width, p.Height, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, w, h, s, hist)
+	})
+}
+
+// Save writes the histogram to path; a .svg extension selects SVG, anything
+// else PNG.
+func (p HistogramPlot) Save(path string, hist map[string]int) error {
+	return savePlot(path, func() (image.Image, error) { return p.Render(hist) }, func() (string, error) { return p.SVG(hist) })
+}
+
+func (p HistogramPlot) draw(cv canvas, w, h float64, s Style, hist map[string]int) error {
+	total := 0
+	for k, n := range hist {
+		if n < 0 {
+			return fmt.Errorf("renderer: negative count %d for state %q", n, k)
+		}
+		total += n
+	}
+	if total == 0 && len(p.Ideal) == 0 {
+		return fmt.Errorf("renderer: histogram is empty")
+	}
+
+	// Union of measured and ideal states, in bit-string order.
+	keys := make([]string, 0, len(hist)+len(p.Ideal))
+	seen := make(map[string]bool)
+	for _, m := range []map[string]float64{p.measured(hist, total), p.Ideal} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	measured := p.measured(hist, total)
+	top := 0.0
+	for _, k := range keys {
+		top = math.Max(top, math.Max(measured[k], p.Ideal[k]))
+	}
+
+	title := p.Title
+	if title == "" {
+		title = fmt.Sprintf("%d shots", total)
+	}
+	if len(p.Ideal) > 0 {
+		title += "  (dashed: ideal)"
+	}
+	a := newAxes(w, h, s, title, "probability", keys)
+	a.frame(cv, niceCeil(top))
+	for i, k := range keys {
+		x0, bw := a.bar(i)
+		ideal, hasIdeal := p.Ideal[k]
+		if pr := measured[k]; pr > 0 {
+			y := a.yOf(pr)
+			cv.rect(x0, y, bw, a.y0-y, s.Accent, nil)
+			if a.roomy() {
+				// Keep the label clear of the ideal marker.
+				cv.text(fmt.Sprintf("%.1f%%", 100*pr), x0+bw/2, math.Min(y, a.yOf(ideal))-2*s.Scale, 0.5, 0, s.Label)
+			}
+		}
+		if hasIdeal {
+			y := a.yOf(ideal)
+			cv.line(x0-bw*0.15, y, x0+bw*1.15, y, s.Label, true)
+		}
+	}
+	return nil
+}
+
+// measured converts counts to probabilities.
+func (p HistogramPlot) measured(hist map[string]int, total int) map[string]float64 {
+	m := make(map[string]float64, len(hist))
+	for k, n := range hist {
+		if total > 0 {
+			m[k] = float64(n) / float64(total)
+		}
+	}
+	return m
+}
+
+// StatevectorPlot draws the amplitudes of a statevector: bar heights are
+// magnitudes, bar colors (and labels) are phases.
+type StatevectorPlot struct {
+	Width, Height int
+	Style         Style
+	Title         string
+}
+
+// NewStatevectorPlot returns a statevector plot of the given size in pixels.
+func NewStatevectorPlot(width, height int) StatevectorPlot {
+	return StatevectorPlot{Width: width, Height: height, Style: LightStyle()}
+}
+
+// Render draws the amplitudes as an image.
+func (p StatevectorPlot) Render(amps []complex128) (image.Image, error) {
+	return renderPNG(p.Width, p.Height, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, w, h, s, amps)
+	})
+}
+
+// SVG draws the amplitudes as an SVG document.
+func (p StatevectorPlot) SVG(amps []complex128) (string, error) {
+	return renderSVG(p.Width, p.Height, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, w, h, s, amps)
+	})
+}
+
+// Save writes the plot to path; a .svg extension selects SVG, anything else PNG.
+func (p StatevectorPlot) Save(path string, amps []complex128) error {
+	return savePlot(path, func() (image.Image, error) { return p.Render(amps) }, func() (string, error) { return p.SVG(amps) })
+}
+
+// maxDenseStates is the largest statevector drawn with every basis state;
+// bigger ones only show the non-zero amplitudes.
+const maxDenseStates = 32
+
+func (p StatevectorPlot) draw(cv canvas, w, h float64, s Style, amps []complex128) error {
+	n, err := qubitsOf(amps)
+	if err != nil {
+		return err
+	}
+	var idx []int
+	top := 0.0
+	for i, a := range amps {
+		if len(amps) <= maxDenseStates || cmplx.Abs(a) > 1e-9 {
+			idx = append(idx, i)
+			top = math.Max(top, cmplx.Abs(a))
+		}
+	}
+	keys := make([]string, len(idx))
+	for j, i := range idx {
+		keys[j] = basisLabel(i, n)
+	}
+
+	title := p.Title
+	if title == "" {
+		title = fmt.Sprintf("%d-qubit statevector", n)
+	}
+	a := newAxes(w, h, s, title, "|amplitude|", keys)
+	a.frame(cv, niceCeil(top))
+	for j, i := range idx {
+		mag := cmplx.Abs(amps[i])
+		if mag < 1e-12 {
+			continue
+		}
+		x0, bw := a.bar(j)
+		y := a.yOf(mag)
+		phase := cmplx.Phase(amps[i])
+		cv.rect(x0, y, bw, a.y0-y, phaseColor(phase), nil)
+		if a.roomy() {
+			cv.text(phaseLabel(phase), x0+bw/2, y-2*s.Scale, 0.5, 0, s.Label)
+		}
+	}
+	return nil
+}
+
+// BlochPlot draws the reduced single-qubit states of a statevector on Bloch
+// spheres, one Size×Size tile per qubit.
+type BlochPlot struct {
+	Size  int
+	Style Style
+}
+
+// NewBlochPlot returns a Bloch-sphere plot with tiles of the given size.
+func NewBlochPlot(size int) BlochPlot { return BlochPlot{Size: size, Style: LightStyle()} }
+
+// Render draws one sphere per qubit, left to right starting with qubit 0.
+func (p BlochPlot) Render(amps []complex128) (image.Image, error) {
+	n, err := qubitsOf(amps)
+	if err != nil {
+		return nil, err
+	}
+	return renderPNG(p.Size*n, p.Size, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, h, s, amps, allQubits(n))
+	})
+}
+
+// RenderQubit draws the sphere of a single qubit.
+func (p BlochPlot) RenderQubit(amps []complex128, qubit int) (image.Image, error) {
+	return renderPNG(p.Size, p.Size, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, h, s, amps, []int{qubit})
+	})
+}
+
+// SVG draws one sphere per qubit as an SVG document.
+func (p BlochPlot) SVG(amps []complex128) (string, error) {
+	n, err := qubitsOf(amps)
+	if err != nil {
+		return "", err
+	}
+	return renderSVG(p.Size*n, p.Size, p.Style, func(cv canvas, w, h float64, s Style) error {
+		return p.draw(cv, h, s, amps, allQubits(n))
+	})
+}
+
+// Save writes the spheres to path; a .svg extension selects SVG, anything else PNG.
+func (p BlochPlot) Save(path string, amps []complex128) error {
+	return savePlot(path, func() (image.Image, error) { return p.Render(amps) }, func() (string, error) { return p.SVG(amps) })
+}
+
+func (p BlochPlot) draw(cv canvas, size float64, s Style, amps []complex128, qubits []int) error {
+	f := s.FontSize * s.Scale
+	for tile, q := range qubits {
+		x, y, z, err := BlochVector(amps, q)
+		if err != nil {
+			return err
+		}
+		// Room for |0> above, |1> and the caption below the sphere.
+		r := (size-4.2*f)/2 - s.Scale
+		cx, cy := float64(tile)*size+size/2, 1.3*f+r
+		// Oblique view: z up, y right, x towards the viewer (squashed).
+		project := func(x, y, z float64) (float64, float64) { return cx + r*y, cy - r*z + 0.35*r*x }
+
+		cv.circle(cx, cy, r, nil, s.Wire)
+		cv.ellipse(cx, cy, r, 0.35*r, s.Wire, true)
+		cv.line(cx, cy-r, cx, cy+r, s.Wire, true)
+		cv.line(cx-r, cy, cx+r, cy, s.Wire, true)
+		cv.text("|0>", cx, cy-r-2*s.Scale, 0.5, 0, s.Label)
+		cv.text("|1>", cx, cy+r+2*s.Scale, 0.5, 1, s.Label)
+		cv.text("y", cx+r+3*s.Scale, cy, 0, 0.5, s.Label)
+		px, py := project(1, 0, 0)
+		cv.text("x", px+3*s.Scale, py, 0, 0, s.Label)
+
+		tx, ty := project(x, y, z)
+		cv.line(cx, cy, tx, ty, s.Accent, false)
+		cv.circle(tx, ty, 3*s.Scale, s.Accent, nil)
+		// Rounding first keeps "-0.00" out of the caption.
+		round := func(v float64) float64 { return math.Round(v*100)/100 + 0 }
+		cv.text(fmt.Sprintf("q%d (%.2f, %.2f, %.2f)", q, round(x), round(y), round(z)), cx, cy+r+2*s.Scale+1.7*f, 0.5, 0.5, s.Label)
+	}
+	return nil
+}
+
+// BlochVector returns the Bloch vector of the reduced state of qubit, where
+// bit q of an amplitude index is the value of qubit q. Entangled qubits have
+// vectors shorter than one.
+func BlochVector(amps []complex128, qubit int) (x, y, z float64, err error) {
+	n, err := qubitsOf(amps)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if qubit < 0 || qubit >= n {
+		return 0, 0, 0, fmt.Errorf("renderer: qubit %d out of range for %d-qubit state", qubit, n)
+	}
+	mask := 1 << qubit
+	var rho00, rho11 float64
+	var rho01 complex128
+	for i, a := range amps {
+		if i&mask != 0 {
+			continue
+		}
+		b := amps[i|mask]
+		rho00 += real(a * cmplx.Conj(a))
+		rho11 += real(b * cmplx.Conj(b))
+		rho01 += a * cmplx.Conj(b)
+	}
+	return 2 * real(rho01), -2 * imag(rho01), rho00 - rho11, nil
+}
+
+// ─── plot helpers ─────────────────────────────────────────────────────────
+
+// axes is the layout of a bar chart with one slot per key.
+type axes struct {
+	s                  Style
+	title, ylabel      string
+	keys               []string
+	x0, y0, xEnd, yTop float64 // plot area; y0 is the baseline
+	max                float64
+	slot               float64
+}
+
+func newAxes(w, h float64, s Style, title, ylabel string, keys []string) *axes {
+	f := s.FontSize * s.Scale
+	a := &axes{s: s, title: title, ylabel: ylabel, keys: keys}
+	a.x0 = 5 * f
+	a.xEnd = w - f
+	a.yTop = 2.5 * f
+	a.slot = (a.xEnd - a.x0) / float64(max(len(keys), 1))
+	if a.vertical() {
+		a.y0 = h - (float64(a.longest())*0.65+1.5)*f
+	} else {
+		a.y0 = h - 2.5*f
+	}
+	return a
+}
+
+// frame draws the title, the axes with a grid up to top and the key labels.
+func (a *axes) frame(cv canvas, top float64) {
+	s := a.s
+	f := s.FontSize * s.Scale
+	a.max = top
+	cv.text(a.title, (a.x0+a.xEnd)/2, f, 0.5, 0.5, s.Label)
+	cv.vtext(a.ylabel, f, (a.yTop+a.y0)/2, s.Label)
+	for i := 0; i <= 4; i++ {
+		v := top * float64(i) / 4
+		y := a.yOf(v)
+		if i > 0 {
+			cv.line(a.x0, y, a.xEnd, y, blend(s.Background, s.Wire, 0.2), false)
+		}
+		cv.text(fmt.Sprintf("%.2g", v), a.x0-4*s.Scale, y, 1, 0.5, s.Label)
+	}
+	cv.line(a.x0, a.y0, a.xEnd, a.y0, s.Wire, false)
+	cv.line(a.x0, a.yTop, a.x0, a.y0, s.Wire, false)
+	// Skip key labels that would overlap their neighbours.
+	every := max(1, int(math.Ceil(1.2*f/a.slot)))
+	for i, k := range a.keys {
+		if i%every != 0 {
+			continue
+		}
+		x := a.x0 + (float64(i)+0.5)*a.slot
+		if a.vertical() {
+			cv.vtext(k, x, a.y0+4*s.Scale, s.Label)
+		} else {
+			cv.text(k, x, a.y0+4*s.Scale, 0.5, 1, s.Label)
+		}
+	}
+}
+
+// bar returns the left edge and width of the i-th bar.
+func (a *axes) bar(i int) (float64, float64) {
+	return a.x0 + (float64(i)+0.15)*a.slot, a.slot * 0.7
+}
+
+// yOf maps a value to its vertical position.
+func (a *axes) yOf(v float64) float64 { return a.y0 - (a.y0-a.yTop)*v/a.max }
+
+// vertical reports whether the key labels are too wide to sit side by side.
+func (a *axes) vertical() bool {
+	return float64(a.longest())*0.65*a.s.FontSize*a.s.Scale > a.slot*0.9
+}
+
+// longest is the length of the longest key.
+func (a *axes) longest() int {
+	n := 0
+	for _, k := range a.keys {
+		n = max(n, len(k))
+	}
+	return n
+}
+
+// roomy reports whether there is space for a value label above each bar.
+func (a *axes) roomy() bool { return a.slot >= 3.5*a.s.FontSize*a.s.Scale }
+
+// renderPNG runs draw on a gg canvas of the scaled size.
+func renderPNG(w, h int, st Style, draw func(cv canvas, w, h float64, s Style) error) (image.Image, error) {
+	s := st.withDefaults()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("renderer: invalid plot size %dx%d", w, h)
+	}
+	sw, sh := int(float64(w)*s.Scale), int(float64(h)*s.Scale)
+	cv, err := newGGCanvas(sw, sh, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := draw(cv, float64(sw), float64(sh), s); err != nil {
+		return nil, err
+	}
+	return cv.dc.Image(), nil
+}
+
+// renderSVG runs draw on an SVG canvas of the scaled size.
+func renderSVG(w, h int, st Style, draw func(cv canvas, w, h float64, s Style) error) (string, error) {
+	s := st.withDefaults()
+	if w <= 0 || h <= 0 {
+		return "", fmt.Errorf("renderer: invalid plot size %dx%d", w, h)
+	}
+	sw, sh := int(float64(w)*s.Scale), int(float64(h)*s.Scale)
+	cv := newSVGCanvas(sw, sh, s)
+	if err := draw(cv, float64(sw), float64(sh), s); err != nil {
+		return "", err
+	}
+	return cv.String(), nil
+}
+
+// savePlot writes either format depending on the file extension.
+func savePlot(path string, png func() (image.Image, error), svg func() (string, error)) error {
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		doc, err := svg()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(doc), 0o644)
+	}
+	img, err := png()
+	if err != nil {
+		return err
+	}
+	return savePNG(path, img)
+}
+
+func savePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// qubitsOf returns the number of qubits of a statevector.
+func qubitsOf(amps []complex128) (int, error) {
+	if len(amps) == 0 || len(amps)&(len(amps)-1) != 0 {
+		return 0, fmt.Errorf("renderer: statevector length %d is not a power of two", len(amps))
+	}
+	return bits.TrailingZeros(uint(len(amps))), nil
+}
+
+func allQubits(n int) []int {
+	qs := make([]int, n)
+	for i := range qs {
+		qs[i] = i
+	}
+	return qs
+}
+
+// basisLabel writes basis state i of n qubits with qubit 0 first.
+func basisLabel(i, n int) string {
+	b := make([]byte, n)
+	for q := 0; q < n; q++ {
+		b[q] = '0' + byte(i>>q&1)
+	}
+	return string(b)
+}
+
+// niceCeil rounds a positive axis maximum up to a tenth (at least 0.1).
+func niceCeil(v float64) float64 {
+	return math.Max(0.1, math.Ceil(v*10-1e-9)/10)
+}
+
+// phaseColor maps a phase in (-π, π] onto the hue circle (0 is red).
+func phaseColor(phase float64) color.Color {
+	h := math.Mod(phase/(2*math.Pi)+1, 1) * 6
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	// Slightly darkened so white labels and backgrounds stay readable.
+	return color.RGBA{uint8(40 + 190*r), uint8(40 + 190*g), uint8(40 + 190*b), 0xff}
+}
+
+// phaseLabel prints a phase in degrees.
+func phaseLabel(phase float64) string {
+	d := math.Round(phase * 180 / math.Pi)
+	if d == -180 {
+		d = 180
+	}
+	return fmt.Sprintf("%g°", d+0) // +0 turns -0 into 0
+}
+
+// blend mixes two colors, t=0 giving a and t=1 giving b.
+func blend(a, b color.Color, t float64) color.Color {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	mix := func(x, y uint8) uint8 { return uint8(float64(x)*(1-t) + float64(y)*t) }
+	return color.NRGBA{mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B), 0xff}
+}
@@ -0,0 +1,135 @@
+package renderer
+
+import (
+	"math"
+	"math/cmplx"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bellAmplitudes is (|00⟩ + |11⟩)/√2.
+func bellAmplitudes() []complex128 {
+	return []complex128{complex(1/math.Sqrt2, 0), 0, 0, complex(1/math.Sqrt2, 0)}
+}
+
+func TestHistogramPlot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	p := NewHistogramPlot(320, 200)
+	p.Ideal = map[string]float64{"00": 0.5, "11": 0.5}
+	hist := map[string]int{"11": 48, "00": 50, "01": 2}
+
+	img, err := p.Render(hist)
+	require.NoError(err)
+	assert.Equal(320, img.Bounds().Dx())
+	assert.Equal(200, img.Bounds().Dy())
+
+	svg, err := p.SVG(hist)
+	require.NoError(err)
+	assert.True(strings.HasPrefix(svg, "<svg "), "SVG document expected")
+	assert.Contains(svg, "50.0%")
+	assert.Contains(svg, "stroke-dasharray", "ideal overlay should be dashed")
+	// States are sorted.
+	assert.Less(strings.Index(svg, ">00<"), strings.Index(svg, ">01<"))
+	assert.Less(strings.Index(svg, ">01<"), strings.Index(svg, ">11<"))
+
+	_, err = p.Render(map[string]int{"0": -1})
+	assert.Error(err, "negative counts are rejected")
+	_, err = NewHistogramPlot(320, 200).Render(nil)
+	assert.Error(err, "empty histograms are rejected")
+	_, err = NewHistogramPlot(0, 200).Render(hist)
+	assert.Error(err, "invalid sizes are rejected")
+}
+
+func TestStatevectorPlot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	amps := []complex128{complex(1/math.Sqrt2, 0), 0, cmplx.Rect(1/math.Sqrt2, math.Pi/2), 0}
+	svg, err := NewStatevectorPlot(320, 200).SVG(amps)
+	require.NoError(err)
+	assert.Contains(svg, "90°")
+	assert.Contains(svg, ">01<", "basis states are written qubit 0 first")
+
+	style := LightStyle()
+	style.Scale = 2
+	p := NewStatevectorPlot(320, 200)
+	p.Style = style
+	img, err := p.Render(amps)
+	require.NoError(err)
+	assert.Equal(640, img.Bounds().Dx(), "plots honour the style scale")
+
+	_, err = NewStatevectorPlot(320, 200).Render(make([]complex128, 3))
+	assert.Error(err)
+}
+
+func TestBlochVector(t *testing.T) {
+	cases := []struct {
+		name    string
+		amps    []complex128
+		qubit   int
+		x, y, z float64
+	}{
+		{"zero", []complex128{1, 0}, 0, 0, 0, 1},
+		{"one", []complex128{0, 1}, 0, 0, 0, -1},
+		{"plus", []complex128{complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0)}, 0, 1, 0, 0},
+		{"plus i", []complex128{complex(1/math.Sqrt2, 0), complex(0, 1/math.Sqrt2)}, 0, 0, 1, 0},
+		{"q1 of |10⟩", []complex128{0, 0, 1, 0}, 1, 0, 0, -1},
+		{"entangled", bellAmplitudes(), 1, 0, 0, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			x, y, z, err := BlochVector(tc.amps, tc.qubit)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.x, x, 1e-9)
+			assert.InDelta(t, tc.y, y, 1e-9)
+			assert.InDelta(t, tc.z, z, 1e-9)
+		})
+	}
+
+	_, _, _, err := BlochVector(bellAmplitudes(), 2)
+	assert.Error(t, err)
+}
+
+func TestBlochPlot(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	p := NewBlochPlot(120)
+	img, err := p.Render(bellAmplitudes())
+	require.NoError(err)
+	assert.Equal(240, img.Bounds().Dx(), "one tile per qubit")
+	assert.Equal(120, img.Bounds().Dy())
+
+	img, err = p.RenderQubit(bellAmplitudes(), 1)
+	require.NoError(err)
+	assert.Equal(120, img.Bounds().Dx())
+
+	svg, err := p.SVG(bellAmplitudes())
+	require.NoError(err)
+	assert.Contains(svg, "q1 (0.00, 0.00, 0.00)")
+}
+
+func TestPlot_Save(t *testing.T) {
+	pngPath, cleanupPNG := tempTestFile(t, "hist.png")
+	defer cleanupPNG()
+	svgPath, cleanupSVG := tempTestFile(t, "hist.svg")
+	defer cleanupSVG()
+
+	p := NewHistogramPlot(320, 200)
+	hist := map[string]int{"0": 1, "1": 1}
+	require.NoError(t, p.Save(pngPath, hist))
+	require.NoError(t, p.Save(svgPath, hist))
+
+	data, err := os.ReadFile(pngPath)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG", string(data[:4]))
+	data, err = os.ReadFile(svgPath)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "<svg "))
+}
@@ -0,0 +1,151 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// ─── quantikz exporter ────────────────────────────────────────────────────
+// Quantikz emits LaTeX source for the quantikz package so circuits can be
+// typeset in papers. Every qubit (and classical bit) is a matrix row and
+// every Operation.TimeStep a matrix column, so the layout matches the PNG
+// and text renderers; like the text renderer, operations of one step whose
+// vertical wires would overlap are spread over extra columns. Only commands
+// available in both the legacy and the 1.x quantikz releases are used
+// (\qw, \cw, \vcw…).
+
+type Quantikz struct{}
+
+// NewQuantikzExporter returns a quantikz exporter.
+func NewQuantikzExporter() Quantikz { return Quantikz{} }
+
+// RenderString returns the circuit as a \begin{quantikz}…\end{quantikz} block.
+func (r Quantikz) RenderString(c circuit.Circuit) (string, error) {
+	qubits, clbits := c.Qubits(), c.Clbits()
+	span := func(op circuit.Operation) (int, int) {
+		lo, hi := min(op.Qubits...), max(op.Qubits...)
+		if op.G.Name() == "MEASURE" && op.Cbit >= 0 {
+			hi = qubits + op.Cbit
+		}
+		return lo, hi
+	}
+
+	// Assign matrix columns: one per time step, split where wires overlap.
+	var layers [][]circuit.Operation
+	ops := c.Operations()
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end].TimeStep == ops[start].TimeStep {
+			end++
+		}
+		layers = append(layers, packLayers(ops[start:end], span)...)
+		start = end
+	}
+	cols := len(layers) + 2 // label column + layers + trailing wire
+
+	cells := make([][]string, qubits+clbits)
+	for row := range cells {
+		cells[row] = make([]string, cols)
+		fill := `\qw`
+		label := fmt.Sprintf(`\lstick{$q_{%d}$}`, row)
+		if row >= qubits {
+			fill = `\cw`
+			label = fmt.Sprintf(`\lstick{$c_{%d}$}`, row-qubits)
+		}
+		cells[row][0] = label
+		for col := 1; col < cols; col++ {
+			cells[row][col] = fill
+		}
+	}
+
+	for i, layer := range layers {
+		for _, op := range layer {
+			if err := r.place(cells, i+1, qubits, clbits, op); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\\begin{quantikz}\n")
+	for row, line := range cells {
+		sb.WriteString(strings.Join(line, " & "))
+		if row < len(cells)-1 {
+			sb.WriteString(` \\`)
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("\\end{quantikz}\n")
+	return sb.String(), nil
+}
+
+// place writes the quantikz commands of op into column col.
+func (r Quantikz) place(cells [][]string, col, qubits, clbits int, op circuit.Operation) error {
+	if len(op.Qubits) != op.G.QubitSpan() {
+		return fmt.Errorf("renderer: %s gate at step %d does not have %d qubits: %v",
+			op.G.Name(), op.TimeStep, op.G.QubitSpan(), op.Qubits)
+	}
+	set := func(i int, cmd string) { cells[op.Qubits[i]][col] = cmd }
+	// offset is the signed row distance between the i-th and j-th qubit.
+	offset := func(i, j int) int { return op.Qubits[j] - op.Qubits[i] }
+
+	switch op.G.Name() {
+	case "H", "X", "Y", "Z", "S":
+		set(0, fmt.Sprintf(`\gate{%s}`, op.G.Name()))
+	case "MEASURE":
+		cmd := `\meter{}`
+		if op.Cbit >= 0 && op.Cbit < clbits {
+			cmd += fmt.Sprintf(` \vcw{%d}`, qubits+op.Cbit-op.Qubits[0])
+		}
+		set(0, cmd)
+	case "CNOT":
+		set(0, fmt.Sprintf(`\ctrl{%d}`, offset(0, 1)))
+		set(1, `\targ{}`)
+	case "CZ":
+		set(0, fmt.Sprintf(`\ctrl{%d}`, offset(0, 1)))
+		set(1, `\control{}`)
+	case "SWAP":
+		set(0, fmt.Sprintf(`\swap{%d}`, offset(0, 1)))
+		set(1, `\targX{}`)
+	case "TOFFOLI":
+		set(0, fmt.Sprintf(`\ctrl{%d}`, offset(0, 2)))
+		set(1, fmt.Sprintf(`\ctrl{%d}`, offset(1, 2)))
+		set(2, `\targ{}`)
+	case "FREDKIN":
+		set(0, fmt.Sprintf(`\ctrl{%d}`, offset(0, 1)))
+		set(1, fmt.Sprintf(`\swap{%d}`, offset(1, 2)))
+		set(2, `\targX{}`)
+	default:
+		if op.G.QubitSpan() != 1 {
+			return fmt.Errorf("renderer: unsupported or unknown gate type '%s'", op.G.Name())
+		}
+		set(0, fmt.Sprintf(`\gate{%s}`, latexEscaper.Replace(op.G.DrawSymbol())))
+	}
+	return nil
+}
+
+// Save writes the quantikz source to a .tex file.
+func (r Quantikz) Save(path string, c circuit.Circuit) error {
+	s, err := r.RenderString(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(s), 0o644)
+}
+
+// latexEscaper protects LaTeX special characters in free-form gate labels.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\^{}`,
+	`~`, `\~{}`,
+)
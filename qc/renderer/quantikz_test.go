@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Regenerate the golden files with: go test ./qc/renderer -run Quantikz -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestQuantikzInterfaces(t *testing.T) {
+	var _ TextRenderer = Quantikz{}
+}
+
+func TestQuantikz_Golden(t *testing.T) {
+	cases := []struct {
+		name  string
+		build func() builder.Builder
+	}{
+		{"h", func() builder.Builder { return builder.New(builder.Q(1)).H(0) }},
+		{"x", func() builder.Builder { return builder.New(builder.Q(1)).X(0) }},
+		{"y", func() builder.Builder { return builder.New(builder.Q(1)).Y(0) }},
+		{"z", func() builder.Builder { return builder.New(builder.Q(1)).Z(0) }},
+		{"s", func() builder.Builder { return builder.New(builder.Q(1)).S(0) }},
+		{"cnot", func() builder.Builder { return builder.New(builder.Q(2)).CNOT(0, 1).CNOT(1, 0) }},
+		{"cz", func() builder.Builder { return builder.New(builder.Q(2)).CZ(0, 1) }},
+		{"swap", func() builder.Builder { return builder.New(builder.Q(2)).SWAP(0, 1) }},
+		{"toffoli", func() builder.Builder { return builder.New(builder.Q(3)).Toffoli(0, 1, 2).Toffoli(0, 2, 1) }},
+		{"fredkin", func() builder.Builder { return builder.New(builder.Q(3)).Fredkin(0, 1, 2).Fredkin(1, 0, 2) }},
+		{"measure", func() builder.Builder { return builder.New(builder.Q(2), builder.C(2)).Measure(0, 1).Measure(1, 0) }},
+		{"bell", func() builder.Builder {
+			return builder.New(builder.Q(2), builder.C(2)).H(0).CNOT(0, 1).Measure(0, 0).Measure(1, 1)
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := tc.build().BuildCircuit()
+			require.NoError(t, err)
+
+			got, err := NewQuantikzExporter().RenderString(c)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "quantikz", tc.name+".tex")
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err, "missing golden file, run with -update")
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestQuantikz_Empty(t *testing.T) {
+	c, err := builder.New(builder.Q(1)).BuildCircuit()
+	require.NoError(t, err)
+
+	got, err := NewQuantikzExporter().RenderString(c)
+	require.NoError(t, err)
+	assert.Equal(t, "\\begin{quantikz}\n\\lstick{$q_{0}$} & \\qw\n\\end{quantikz}\n", got)
+}
+
+func TestQuantikz_Save(t *testing.T) {
+	b := builder.New(builder.Q(1))
+	b.H(0)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+
+	path, cleanup := tempTestFile(t, "h.tex")
+	defer cleanup()
+	require.NoError(t, NewQuantikzExporter().Save(path, c))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `\gate{H}`)
+}
@@ -13,6 +13,11 @@ type Renderer interface {
 	Render(c circuit.Circuit) (image.Image, error)
 }
 
+// TextRenderer turns a circuit into printable text (terminal diagrams, markup…).
+type TextRenderer interface {
+	RenderString(c circuit.Circuit) (string, error)
+}
+
 // Defaultsize & look‑n‑feel knobs
 var (
 	WireColor  = color.Black
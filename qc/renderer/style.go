@@ -0,0 +1,242 @@
+package renderer
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"sync"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// ─── styles & themes ──────────────────────────────────────────────────────
+// Style collects every look‑n‑feel knob of the PNG renderer. Zero fields fall
+// back to the light theme, so a partially filled Style is valid. Gate colors
+// are grouped by family (see GateFamily) rather than by gate name.
+
+// GateColors is the palette of one gate family.
+type GateColors struct {
+	Fill   color.Color // box background (box gates only)
+	Stroke color.Color // outline, control dots, ⊕ and × symbols
+	Text   color.Color // gate label
+}
+
+// Gate families used as keys of Style.Gates.
+const (
+	FamilyPauli      = "pauli"      // X, Y, Z
+	FamilyHadamard   = "hadamard"   // H
+	FamilyPhase      = "phase"      // S and other phase gates
+	FamilyControlled = "controlled" // CNOT, CZ, TOFFOLI
+	FamilySwap       = "swap"       // SWAP, FREDKIN
+	FamilyMeasure    = "measure"    // MEASURE
+	FamilyOther      = "other"      // anything else
+)
+
+// GateFamily returns the family a gate name belongs to.
+func GateFamily(name string) string {
+	switch name {
+	case "X", "Y", "Z":
+		return FamilyPauli
+	case "H":
+		return FamilyHadamard
+	case "S":
+		return FamilyPhase
+	case "CNOT", "CZ", "TOFFOLI":
+		return FamilyControlled
+	case "SWAP", "FREDKIN":
+		return FamilySwap
+	case "MEASURE":
+		return FamilyMeasure
+	default:
+		return FamilyOther
+	}
+}
+
+// Style holds the look shared by the circuit renderers and the plots: the
+// colors of the background, wires, labels and plot marks, the gate palette
+// per family with a fallback for the rest, and the font and geometry
+// settings. Zero or nil numeric fields take the defaults noted on them;
+// Theme and LightStyle return complete styles.
+type Style struct {
+	Background color.Color
+	Wire       color.Color // quantum wires
+	Classical  color.Color // classical wires and measurement links
+	Label      color.Color // register labels (q0, c0…)
+	Accent     color.Color // bars and vectors of plots
+
+	// Gates overrides the default gate palette per family.
+	Gates map[string]GateColors
+	// GateFill, GateStroke and GateText are used for families missing from Gates.
+	GateFill   color.Color
+	GateStroke color.Color
+	GateText   color.Color
+
+	// FontPath is a TrueType file used for labels; empty means Go Regular.
+	FontPath string
+	// FontSize is the label size in points before scaling (0 = 13).
+	FontSize float64
+	// LineWidth is the stroke width in pixels before scaling (0 = 1).
+	LineWidth float64
+	// Padding is the gap between a gate box and its cell, as a fraction of
+	// the cell (nil = 0.15). It is a pointer so that 0 can ask for boxes
+	// filling their cells; values outside [0, 0.5) also select 0.15.
+	Padding *float64
+	// Scale multiplies every length, e.g. 2 for high-DPI displays (0 = 1).
+	Scale float64
+	// Compact narrows the columns and the classical register band.
+	Compact bool
+}
+
+// LightStyle is the default black-on-white look.
+func LightStyle() Style {
+	return Style{
+		Background: color.White,
+		Wire:       WireColor,
+		Classical:  WireColor,
+		Label:      WireColor,
+		Accent:     color.RGBA{0x1f, 0x77, 0xb4, 0xff},
+		GateFill:   GateFill,
+		GateStroke: GateStroke,
+		GateText:   GateStroke,
+	}
+}
+
+// DarkStyle draws light wires on a dark background.
+func DarkStyle() Style {
+	return Style{
+		Background: color.RGBA{0x1e, 0x1e, 0x1e, 0xff},
+		Wire:       color.RGBA{0xd4, 0xd4, 0xd4, 0xff},
+		Classical:  color.RGBA{0x9c, 0x9c, 0x9c, 0xff},
+		Label:      color.RGBA{0xd4, 0xd4, 0xd4, 0xff},
+		Accent:     color.RGBA{0x4e, 0xa1, 0xff, 0xff},
+		GateFill:   color.RGBA{0x2d, 0x2d, 0x2d, 0xff},
+		GateStroke: color.RGBA{0xd4, 0xd4, 0xd4, 0xff},
+		GateText:   color.RGBA{0xf0, 0xf0, 0xf0, 0xff},
+	}
+}
+
+// ColorStyle colors gates by family, in the spirit of common circuit viewers.
+func ColorStyle() Style {
+	s := LightStyle()
+	s.Gates = map[string]GateColors{
+		FamilyPauli:      {Fill: color.RGBA{0xfa, 0x4d, 0x56, 0xff}, Stroke: color.RGBA{0xa2, 0x19, 0x1f, 0xff}, Text: color.White},
+		FamilyHadamard:   {Fill: color.RGBA{0x33, 0xb1, 0xff, 0xff}, Stroke: color.RGBA{0x00, 0x5d, 0x9a, 0xff}, Text: color.White},
+		FamilyPhase:      {Fill: color.RGBA{0xbe, 0x95, 0xff, 0xff}, Stroke: color.RGBA{0x6e, 0x32, 0xc9, 0xff}, Text: color.White},
+		FamilyControlled: {Stroke: color.RGBA{0x00, 0x2d, 0x9c, 0xff}},
+		FamilySwap:       {Stroke: color.RGBA{0x00, 0x7d, 0x79, 0xff}},
+		FamilyMeasure:    {Fill: color.RGBA{0x8d, 0x8d, 0x8d, 0xff}, Stroke: color.Black, Text: color.Black},
+	}
+	return s
+}
+
+// themes maps the names accepted by Theme to their constructors.
+var themes = map[string]func() Style{
+	"light": LightStyle,
+	"dark":  DarkStyle,
+	"color": ColorStyle,
+	"compact": func() Style {
+		s := LightStyle()
+		s.Compact = true
+		return s
+	},
+}
+
+// Theme returns the named built-in style ("" selects "light").
+func Theme(name string) (Style, error) {
+	if name == "" {
+		name = "light"
+	}
+	f, ok := themes[name]
+	if !ok {
+		return Style{}, fmt.Errorf("renderer: unknown theme '%s'", name)
+	}
+	return f(), nil
+}
+
+// Themes lists the built-in theme names in alphabetical order.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for n := range themes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withDefaults fills zero fields from the light theme.
+func (s Style) withDefaults() Style {
+	d := LightStyle()
+	pick := func(c, def color.Color) color.Color {
+		if c == nil {
+			return def
+		}
+		return c
+	}
+	s.Background = pick(s.Background, d.Background)
+	s.Wire = pick(s.Wire, d.Wire)
+	s.Classical = pick(s.Classical, s.Wire)
+	s.Label = pick(s.Label, s.Wire)
+	s.Accent = pick(s.Accent, d.Accent)
+	s.GateFill = pick(s.GateFill, d.GateFill)
+	s.GateStroke = pick(s.GateStroke, s.Wire)
+	s.GateText = pick(s.GateText, s.GateStroke)
+	if s.FontSize <= 0 {
+		s.FontSize = 13
+	}
+	if s.LineWidth <= 0 {
+		s.LineWidth = 1
+	}
+	if s.Padding == nil || *s.Padding < 0 || *s.Padding >= 0.5 {
+		padding := 0.15
+		s.Padding = &padding
+	}
+	if s.Scale <= 0 {
+		s.Scale = 1
+	}
+	return s
+}
+
+// gate returns the palette for a gate name, completing missing colors from
+// the generic gate colors.
+func (s Style) gate(name string) GateColors {
+	g := s.Gates[GateFamily(name)]
+	if g.Fill == nil {
+		g.Fill = s.GateFill
+	}
+	if g.Stroke == nil {
+		g.Stroke = s.GateStroke
+	}
+	if g.Text == nil {
+		g.Text = s.GateText
+	}
+	return g
+}
+
+// fontFace loads the label font at the scaled size.
+func (s Style) fontFace() (font.Face, error) {
+	size := s.FontSize * s.Scale
+	if s.FontPath != "" {
+		return gg.LoadFontFace(s.FontPath, size)
+	}
+	goRegularOnce.Do(func() { goRegular, goRegularErr = truetype.Parse(goregular.TTF) })
+	if goRegularErr != nil {
+		return nil, goRegularErr
+	}
+	return truetype.NewFace(goRegular, &truetype.Options{Size: size}), nil
+}
+
+// goRegular is the embedded default font, parsed on first use.
+var (
+	goRegularOnce sync.Once
+	goRegular     *truetype.Font
+	goRegularErr  error
+)
+
+// Option configures a GGPNG renderer.
+type Option func(*GGPNG)
+
+// WithStyle sets the style of the renderer.
+func WithStyle(s Style) Option { return func(r *GGPNG) { r.Style = s } }
@@ -0,0 +1,85 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTheme(t *testing.T) {
+	assert.Equal(t, []string{"color", "compact", "dark", "light"}, Themes())
+
+	for _, name := range Themes() {
+		_, err := Theme(name)
+		assert.NoError(t, err, "theme %s", name)
+	}
+	s, err := Theme("")
+	require.NoError(t, err)
+	assert.Equal(t, LightStyle().Background, s.Background, "empty name selects the light theme")
+
+	_, err = Theme("neon")
+	assert.Error(t, err)
+}
+
+func TestStyle_WithDefaults(t *testing.T) {
+	s := Style{Wire: color.White}.withDefaults()
+	assert.Equal(t, color.White, s.Classical, "classical wires follow the quantum wire color")
+	assert.Equal(t, color.White, s.GateStroke)
+	assert.Equal(t, 1.0, s.Scale)
+	assert.Equal(t, 0.15, *s.Padding)
+	none := 0.0
+	assert.Equal(t, 0.0, *Style{Padding: &none}.withDefaults().Padding, "zero padding is kept")
+
+	c := ColorStyle()
+	assert.Equal(t, c.Gates[FamilyPauli].Fill, c.gate("Y").Fill)
+	assert.Equal(t, c.GateStroke, c.gate("CNOT").Text, "missing family colors fall back to the generic ones")
+	assert.Equal(t, FamilyOther, GateFamily("RX"))
+}
+
+func TestGGPNG_Styles(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	b := builder.New(builder.Q(2), builder.C(1))
+	b.X(0).CNOT(0, 1).Measure(1, 0)
+	c, err := b.BuildCircuit()
+	require.NoError(err)
+
+	base, err := NewRenderer(defaultCellSize).Render(c)
+	require.NoError(err)
+
+	// Dark mode paints the background.
+	dark, err := NewRenderer(defaultCellSize, WithStyle(DarkStyle())).Render(c)
+	require.NoError(err)
+	assert.Equal(color.RGBAModel.Convert(DarkStyle().Background), color.RGBAModel.Convert(dark.At(1, 1)))
+
+	// Gate families get their own fill.
+	colored := NewRenderer(defaultCellSize, WithStyle(ColorStyle()))
+	img, err := colored.Render(c)
+	require.NoError(err)
+	x, y := int(colored.x(0)), int(colored.y(0))
+	r, g, bl, _ := img.At(x-defaultCellSize/5, y-defaultCellSize/5).RGBA()
+	assert.Greater(r, g, "X gate box should be filled with the Pauli color")
+	assert.Greater(r, bl, "X gate box should be filled with the Pauli color")
+
+	// DPI scaling multiplies every length.
+	hidpi := LightStyle()
+	hidpi.Scale = 2
+	big, err := NewRenderer(defaultCellSize, WithStyle(hidpi)).Render(c)
+	require.NoError(err)
+	assert.Equal(2*base.Bounds().Dx(), big.Bounds().Dx())
+	assert.Equal(2*base.Bounds().Dy(), big.Bounds().Dy())
+
+	// Compact mode shrinks the image.
+	small, err := NewRenderer(defaultCellSize, WithStyle(Style{Compact: true})).Render(c)
+	require.NoError(err)
+	assert.Less(small.Bounds().Dx(), base.Bounds().Dx())
+	assert.Less(small.Bounds().Dy(), base.Bounds().Dy())
+
+	// A missing font file is reported.
+	_, err = NewRenderer(defaultCellSize, WithStyle(Style{FontPath: "testdata/missing.ttf"})).Render(c)
+	assert.Error(err)
+}
@@ -0,0 +1,416 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// ─── text renderer ────────────────────────────────────────────────────────
+// Text draws circuits with box-drawing characters so they can be inspected in
+// terminals and CI logs. Columns follow Operation.TimeStep; operations that
+// share a step but would overlap vertically are spread over sub-columns.
+//
+// Every qubit occupies three rows (box top, wire, box bottom) and every
+// classical bit one row below the quantum register:
+//
+//	     ┌───┐       ┌───┐
+//	q0: ─┤ H ├───●───┤ M ├───────
+//	     └───┘   │   └─╥─┘
+//	             │     ║   ┌───┐
+//	q1: ─────────⊕─────╫───┤ M ├─
+//	                   ║   └─╥─┘
+//	c0: ═══════════════╩═════╬═══
+//	c1: ═════════════════════╩═══
+
+type Text struct {
+	Width int  // fold the diagram at this many characters (0 → never fold)
+	ASCII bool // restrict the output to 7-bit ASCII
+}
+
+// NewTextRenderer returns a Unicode text renderer folding at width columns.
+func NewTextRenderer(width int) Text { return Text{Width: width} }
+
+// RenderString draws the circuit and returns it as a newline-terminated string.
+func (r Text) RenderString(c circuit.Circuit) (string, error) {
+	l := newTextLayout(c, r.glyphs())
+
+	var segs []textSegment
+	ops := c.Operations()
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end].TimeStep == ops[start].TimeStep {
+			end++
+		}
+		for _, layer := range packLayers(ops[start:end], l.span) {
+			seg, err := l.column(layer)
+			if err != nil {
+				return "", err
+			}
+			segs = append(segs, seg)
+		}
+		start = end
+	}
+	return l.join(segs, r.Width), nil
+}
+
+// Fprint writes the rendered circuit to w.
+func (r Text) Fprint(w io.Writer, c circuit.Circuit) error {
+	s, err := r.RenderString(c)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, s)
+	return err
+}
+
+// Save writes the rendered circuit to a text file.
+func (r Text) Save(path string, c circuit.Circuit) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Fprint(f, c)
+}
+
+func (r Text) glyphs() textGlyphs {
+	if r.ASCII {
+		return asciiGlyphs
+	}
+	return unicodeGlyphs
+}
+
+// ─── glyph sets ───────────────────────────────────────────────────────────
+
+type textGlyphs struct {
+	wire, cwire       rune // quantum / classical wire
+	vert, cross       rune // quantum link on blank / wire rows
+	mvert, mcrossQ    rune // measurement link on blank / qubit wire rows
+	mcrossC, mend     rune // measurement link crossing / ending on a clbit
+	tl, tr, bl, br, h rune // box corners and horizontal edge
+	left, right       rune // box edges on the wire row
+	mbottom           rune // box bottom where the measurement link starts
+	ctrl, targ, swap  rune // control dot, ⊕ target and SWAP cross
+	foldOut, foldIn   rune // continuation markers for folded diagrams
+}
+
+var unicodeGlyphs = textGlyphs{
+	wire:    '─',
+	cwire:   '═',
+	vert:    '│',
+	cross:   '┼',
+	mvert:   '║',
+	mcrossQ: '╫',
+	mcrossC: '╬',
+	mend:    '╩',
+	tl:      '┌',
+	tr:      '┐',
+	bl:      '└',
+	br:      '┘',
+	h:       '─',
+	left:    '┤',
+	right:   '├',
+	mbottom: '╥',
+	ctrl:    '●',
+	targ:    '⊕',
+	swap:    '×',
+	foldOut: '»',
+	foldIn:  '«',
+}
+
+var asciiGlyphs = textGlyphs{
+	wire:    '-',
+	cwire:   '=',
+	vert:    '|',
+	cross:   '+',
+	mvert:   '|',
+	mcrossQ: '|',
+	mcrossC: '|',
+	mend:    'v',
+	tl:      '+',
+	tr:      '+',
+	bl:      '+',
+	br:      '+',
+	h:       '-',
+	left:    '|',
+	right:   '|',
+	mbottom: '+',
+	ctrl:    '*',
+	targ:    'X',
+	swap:    'x',
+	foldOut: '>',
+	foldIn:  '<',
+}
+
+// ─── layout ───────────────────────────────────────────────────────────────
+
+type rowKind int
+
+const (
+	blankRow rowKind = iota
+	qubitRow
+	clbitRow
+)
+
+// textSegment is one rendered column: rows × runes.
+type textSegment [][]rune
+
+type textLayout struct {
+	g      textGlyphs
+	qubits int
+	clbits int
+	kinds  []rowKind
+	labels []string
+}
+
+func newTextLayout(c circuit.Circuit, g textGlyphs) *textLayout {
+	l := &textLayout{g: g, qubits: c.Qubits(), clbits: c.Clbits()}
+	l.kinds = make([]rowKind, 3*l.qubits+l.clbits)
+	l.labels = make([]string, len(l.kinds))
+	for q := 0; q < l.qubits; q++ {
+		l.kinds[l.wireRow(q)] = qubitRow
+		l.labels[l.wireRow(q)] = fmt.Sprintf("q%d: ", q)
+	}
+	for cb := 0; cb < l.clbits; cb++ {
+		l.kinds[l.clbitRow(cb)] = clbitRow
+		l.labels[l.clbitRow(cb)] = fmt.Sprintf("c%d: ", cb)
+	}
+	return l
+}
+
+func (l *textLayout) wireRow(q int) int   { return 3*q + 1 }
+func (l *textLayout) clbitRow(cb int) int { return 3*l.qubits + cb }
+
+// span returns the first and last row an operation draws on.
+func (l *textLayout) span(op circuit.Operation) (int, int) {
+	lo, hi := op.Qubits[0], op.Qubits[0]
+	for _, q := range op.Qubits[1:] {
+		lo, hi = min(lo, q), max(hi, q)
+	}
+	last := l.wireRow(hi) + 1
+	if op.G.Name() == "MEASURE" && op.Cbit >= 0 {
+		last = l.clbitRow(op.Cbit)
+	}
+	return l.wireRow(lo) - 1, last
+}
+
+// packLayers splits the operations of one time step into sub-columns so that
+// no two operations in a sub-column overlap vertically.
+func packLayers(ops []circuit.Operation, span func(circuit.Operation) (int, int)) [][]circuit.Operation {
+	var layers [][]circuit.Operation
+	var used [][][2]int
+	for _, op := range ops {
+		lo, hi := span(op)
+		placed := false
+		for i := range layers {
+			free := true
+			for _, s := range used[i] {
+				if lo <= s[1] && s[0] <= hi {
+					free = false
+					break
+				}
+			}
+			if free {
+				layers[i] = append(layers[i], op)
+				used[i] = append(used[i], [2]int{lo, hi})
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			layers = append(layers, []circuit.Operation{op})
+			used = append(used, [][2]int{{lo, hi}})
+		}
+	}
+	return layers
+}
+
+// blank returns an empty segment of width w showing only the wires.
+func (l *textLayout) blank(w int) textSegment {
+	seg := make(textSegment, len(l.kinds))
+	for i, k := range l.kinds {
+		fill := ' '
+		switch k {
+		case qubitRow:
+			fill = l.g.wire
+		case clbitRow:
+			fill = l.g.cwire
+		}
+		seg[i] = []rune(strings.Repeat(string(fill), w))
+	}
+	return seg
+}
+
+// column renders one sub-column, including its leading wire gap.
+func (l *textLayout) column(ops []circuit.Operation) (textSegment, error) {
+	w := 5
+	for _, op := range ops {
+		if isBoxed(op) {
+			w = max(w, utf8.RuneCountInString(op.G.DrawSymbol())+4)
+		}
+	}
+	seg := l.blank(w + 1)
+	for _, op := range ops {
+		if err := l.draw(seg, 1, w, op); err != nil {
+			return nil, err
+		}
+	}
+	return seg, nil
+}
+
+// isBoxed reports whether the operation is drawn as a labelled box.
+func isBoxed(op circuit.Operation) bool {
+	switch op.G.Name() {
+	case "CNOT", "CZ", "SWAP", "TOFFOLI", "FREDKIN":
+		return false
+	}
+	return op.G.QubitSpan() == 1
+}
+
+// draw paints op into seg, in the box of width w starting at column x0.
+func (l *textLayout) draw(seg textSegment, x0, w int, op circuit.Operation) error {
+	cx := x0 + w/2
+	if isBoxed(op) {
+		l.drawBox(seg, x0, w, l.wireRow(op.Qubits[0]), op.G.DrawSymbol())
+		if op.G.Name() == "MEASURE" && op.Cbit >= 0 {
+			l.drawMeasureLink(seg, cx, l.wireRow(op.Qubits[0])+1, l.clbitRow(op.Cbit))
+		}
+		return nil
+	}
+
+	var targ rune
+	switch op.G.Name() {
+	case "CNOT", "TOFFOLI":
+		targ = l.g.targ
+	case "CZ":
+		targ = l.g.ctrl
+	case "SWAP", "FREDKIN":
+		targ = l.g.swap
+	default:
+		return fmt.Errorf("renderer: unsupported or unknown gate type '%s'", op.G.Name())
+	}
+	if len(op.Qubits) != op.G.QubitSpan() {
+		return fmt.Errorf("renderer: %s gate at step %d does not have %d qubits: %v",
+			op.G.Name(), op.TimeStep, op.G.QubitSpan(), op.Qubits)
+	}
+
+	top, bottom := l.span(op)
+	for row := top + 2; row < bottom-1; row++ {
+		if l.kinds[row] == qubitRow {
+			seg[row][cx] = l.g.cross
+		} else {
+			seg[row][cx] = l.g.vert
+		}
+	}
+	for _, i := range op.G.Controls() {
+		seg[l.wireRow(op.Qubits[i])][cx] = l.g.ctrl
+	}
+	for _, i := range op.G.Targets() {
+		seg[l.wireRow(op.Qubits[i])][cx] = targ
+	}
+	return nil
+}
+
+func (l *textLayout) drawBox(seg textSegment, x0, w, wire int, label string) {
+	top, mid, bot := seg[wire-1], seg[wire], seg[wire+1]
+	for x := x0; x < x0+w; x++ {
+		top[x], mid[x], bot[x] = l.g.h, ' ', l.g.h
+	}
+	top[x0], top[x0+w-1] = l.g.tl, l.g.tr
+	bot[x0], bot[x0+w-1] = l.g.bl, l.g.br
+	mid[x0], mid[x0+w-1] = l.g.left, l.g.right
+	runes := []rune(label)
+	pad := (w - 2 - len(runes)) / 2
+	copy(mid[x0+1+pad:], runes)
+}
+
+// drawMeasureLink draws the double line from the measurement box bottom (row
+// from-1) down to the classical wire at row to.
+func (l *textLayout) drawMeasureLink(seg textSegment, cx, from, to int) {
+	seg[from][cx] = l.g.mbottom
+	for row := from + 1; row < to; row++ {
+		switch l.kinds[row] {
+		case qubitRow:
+			seg[row][cx] = l.g.mcrossQ
+		case clbitRow:
+			seg[row][cx] = l.g.mcrossC
+		default:
+			seg[row][cx] = l.g.mvert
+		}
+	}
+	seg[to][cx] = l.g.mend
+}
+
+// join concatenates segments behind the register labels, folding into blocks
+// no wider than width (when width > 0).
+func (l *textLayout) join(segs []textSegment, width int) string {
+	labelW := 0
+	for _, s := range l.labels {
+		labelW = max(labelW, utf8.RuneCountInString(s))
+	}
+
+	// Group segments into blocks that fit the requested width.
+	var blocks [][]textSegment
+	cur, curW := []textSegment(nil), 0
+	budget := width - labelW - 3 // «, », trailing wire gap
+	for _, s := range segs {
+		sw := len(s[0])
+		if width > 0 && len(cur) > 0 && curW+sw > budget {
+			blocks = append(blocks, cur)
+			cur, curW = nil, 0
+		}
+		cur = append(cur, s)
+		curW += sw
+	}
+	blocks = append(blocks, cur)
+
+	var sb strings.Builder
+	for bi, block := range blocks {
+		if bi > 0 {
+			sb.WriteByte('\n')
+		}
+		tail := l.blank(1)
+		var lines []string
+		for row, kind := range l.kinds {
+			var line strings.Builder
+			line.WriteString(l.labels[row])
+			line.WriteString(strings.Repeat(" ", labelW-utf8.RuneCountInString(l.labels[row])))
+			wired := kind != blankRow
+			if bi > 0 {
+				line.WriteRune(marker(wired, l.g.foldIn))
+			}
+			for _, s := range block {
+				line.WriteString(string(s[row]))
+			}
+			line.WriteString(string(tail[row]))
+			if bi < len(blocks)-1 {
+				line.WriteRune(marker(wired, l.g.foldOut))
+			}
+			lines = append(lines, strings.TrimRight(line.String(), " "))
+		}
+		// Drop the empty box rows above the first and below the last wire.
+		for len(lines) > 0 && lines[0] == "" {
+			lines = lines[1:]
+		}
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func marker(wired bool, r rune) rune {
+	if wired {
+		return r
+	}
+	return ' '
+}
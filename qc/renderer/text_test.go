@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBellCircuit(t *testing.T) circuit.Circuit {
+	t.Helper()
+	b := builder.New(builder.Q(2), builder.C(2))
+	b.H(0).CNOT(0, 1).Measure(0, 0).Measure(1, 1)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err, "building Bell circuit failed")
+	return c
+}
+
+func TestTextInterfaces(t *testing.T) {
+	var _ TextRenderer = Text{}
+}
+
+func TestText_RenderBell(t *testing.T) {
+	want := "" +
+		"     ┌───┐       ┌───┐\n" +
+		"q0: ─┤ H ├───●───┤ M ├───────\n" +
+		"     └───┘   │   └─╥─┘\n" +
+		"             │     ║   ┌───┐\n" +
+		"q1: ─────────⊕─────╫───┤ M ├─\n" +
+		"                   ║   └─╥─┘\n" +
+		"c0: ═══════════════╩═════╬═══\n" +
+		"c1: ═════════════════════╩═══\n"
+
+	got, err := NewTextRenderer(0).RenderString(newBellCircuit(t))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestText_RenderASCII(t *testing.T) {
+	want := "" +
+		"     +---+       +---+\n" +
+		"q0: -| H |---*---| M |-------\n" +
+		"     +---+   |   +-+-+\n" +
+		"             |     |   +---+\n" +
+		"q1: ---------X-----|---| M |-\n" +
+		"                   |   +-+-+\n" +
+		"c0: ===============v=====|===\n" +
+		"c1: =====================v===\n"
+
+	got, err := Text{ASCII: true}.RenderString(newBellCircuit(t))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	for _, r := range got {
+		assert.Less(t, r, rune(utf8.RuneSelf), "ASCII output should not contain %q", r)
+	}
+}
+
+func TestText_MultiQubitGates(t *testing.T) {
+	b := builder.New(builder.Q(3))
+	b.Toffoli(0, 2, 1).SWAP(0, 2).CZ(2, 0).Fredkin(1, 0, 2)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+
+	want := "" +
+		"q0: ───●─────×─────●─────×───\n" +
+		"       │     │     │     │\n" +
+		"       │     │     │     │\n" +
+		"q1: ───⊕─────┼─────┼─────●───\n" +
+		"       │     │     │     │\n" +
+		"       │     │     │     │\n" +
+		"q2: ───●─────×─────●─────×───\n"
+
+	got, err := NewTextRenderer(0).RenderString(c)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestText_SharedStepUsesSubColumns(t *testing.T) {
+	// CNOT(0,2) and H(1) share time step 0 but overlap vertically.
+	b := builder.New(builder.Q(3))
+	b.CNOT(0, 2).H(1)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+	require.Equal(t, 0, c.MaxStep())
+
+	got, err := NewTextRenderer(0).RenderString(c)
+	require.NoError(t, err)
+	lines := strings.Split(got, "\n")
+	assert.Equal(t, "q1: ───┼───┤ H ├─", lines[3])
+}
+
+func TestText_Fold(t *testing.T) {
+	c := newBellCircuit(t)
+	unfolded, err := NewTextRenderer(0).RenderString(c)
+	require.NoError(t, err)
+
+	folded, err := NewTextRenderer(24).RenderString(c)
+	require.NoError(t, err)
+	assert.NotEqual(t, unfolded, folded)
+
+	var starts, ends []string
+	for _, line := range strings.Split(folded, "\n") {
+		assert.LessOrEqual(t, utf8.RuneCountInString(line), 24, "line %q exceeds fold width", line)
+		if strings.HasPrefix(line, "q0: ") {
+			starts = append(starts, line)
+		}
+		if strings.HasSuffix(line, "»") {
+			ends = append(ends, line)
+		}
+	}
+	require.Len(t, starts, 2, "Bell circuit should fold into two blocks at width 24")
+	assert.True(t, strings.HasSuffix(starts[0], "»"), "first block should end with a continuation marker")
+	assert.True(t, strings.HasPrefix(starts[1], "q0: «"), "second block should start with a continuation marker")
+	assert.Len(t, ends, 4, "every wire of the first block should carry a continuation marker")
+}
+
+func TestText_Empty(t *testing.T) {
+	c, err := builder.New(builder.Q(1)).BuildCircuit()
+	require.NoError(t, err)
+
+	got, err := NewTextRenderer(80).RenderString(c)
+	require.NoError(t, err)
+	assert.Equal(t, "q0: ─\n", got)
+}
+
+func TestText_Save(t *testing.T) {
+	path, cleanup := tempTestFile(t, "bell.txt")
+	defer cleanup()
+
+	r := NewTextRenderer(80)
+	require.NoError(t, r.Save(path, newBellCircuit(t)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	want, err := r.RenderString(newBellCircuit(t))
+	require.NoError(t, err)
+	assert.Equal(t, want, string(data))
+}
@@ -0,0 +1,168 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+type (
+	// Params are the values of one point of a parameter sweep.
+	Params map[string]float64
+
+	// Template builds the circuit of one sweep point.
+	Template func(Params) (circuit.Circuit, error)
+
+	// ItemResult is the outcome of one circuit of RunMany or Sweep. Err is
+	// the build error of a sweep point, or a *PartialRunError whose
+	// histogram is in Counts.
+	ItemResult struct {
+		Params Params // the sweep point; nil for RunMany
+		Counts map[string]int
+		Err    error
+	}
+)
+
+// manyChunk is the number of shots of one circuit a worker takes at a time.
+const manyChunk = 32
+
+// Grid returns the cartesian product of the axes, one point per combination.
+// The last axis in name order varies fastest.
+func Grid(axes map[string][]float64) []Params {
+	names := make([]string, 0, len(axes))
+	for name := range axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	points := []Params{{}}
+	for _, name := range names {
+		next := make([]Params, 0, len(points)*len(axes[name]))
+		for _, p := range points {
+			for _, v := range axes[name] {
+				q := make(Params, len(p)+1)
+				for k, pv := range p {
+					q[k] = pv
+				}
+				q[name] = v
+				next = append(next, q)
+			}
+		}
+		points = next
+	}
+	return points
+}
+
+// RunMany runs every circuit for s.Shots shots. The shots of all circuits
+// share one set of workers, so many small circuits keep every worker busy.
+// Results are in the order of the circuits; a failing circuit stops only its
+// own shots unless ContinueOnError is set. Progress is not reported.
+func (s *Simulator) RunMany(ctx context.Context, circuits []circuit.Circuit) []ItemResult {
+	results := make([]ItemResult, len(circuits))
+	s.runMany(ctx, circuits, results)
+	return results
+}
+
+// Sweep builds the template at every point and runs the circuits like
+// RunMany. Points whose circuit cannot be built get the build error.
+func (s *Simulator) Sweep(ctx context.Context, tmpl Template, points []Params) []ItemResult {
+	results := make([]ItemResult, len(points))
+	circuits := make([]circuit.Circuit, len(points))
+	for i, p := range points {
+		results[i].Params = p
+		c, err := tmpl(p)
+		if err != nil {
+			results[i].Err = fmt.Errorf("building circuit: %w", err)
+			continue
+		}
+		circuits[i] = c
+	}
+	s.runMany(ctx, circuits, results)
+	return results
+}
+
+// runMany runs the non-nil circuits whose results have no error yet.
+func (s *Simulator) runMany(ctx context.Context, circuits []circuit.Circuit, results []ItemResult) {
+	runs := make([]*run, len(circuits))
+	total := 0
+	for i, c := range circuits {
+		if c == nil || results[i].Err != nil {
+			continue
+		}
+		runs[i] = s.newRun(ctx, s.Shots)
+		runs[i].progress = nil
+		total += s.Shots
+	}
+	if total == 0 {
+		return
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, (total+manyChunk-1)/manyChunk)
+
+	s.log.Info().
+		Int("circuits", len(circuits)).
+		Int("shots", total).
+		Int("workers", workers).
+		Msg("itsu: Starting RunMany")
+
+	type chunk struct{ item, shots int }
+	jobs := make(chan chunk)
+	go func() {
+		defer close(jobs)
+		for i, r := range runs {
+			if r == nil {
+				continue
+			}
+			for left := s.Shots; left > 0 && r.ctx.Err() == nil; left -= manyChunk {
+				select {
+				case jobs <- chunk{i, min(left, manyChunk)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner, err := s.acquire()
+			defer s.release(runner)
+			for ch := range jobs {
+				r := runs[ch.item]
+				if err != nil {
+					r.fail(err)
+					continue
+				}
+				wrap := func(err error) error { return fmt.Errorf("circuit %d: %w", ch.item, err) }
+				for range ch.shots {
+					if !r.shot(runner, circuits[ch.item], wrap) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, r := range runs {
+		if r == nil {
+			continue
+		}
+		results[i].Counts, results[i].Err = r.finish(ctx)
+		if results[i].Err != nil {
+			failed++
+		}
+	}
+	s.log.Info().Int("circuits", len(circuits)).Int("failed", failed).Msg("itsu: RunMany finished")
+}
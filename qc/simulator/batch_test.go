@@ -0,0 +1,118 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qubitsCircuit builds an n-qubit circuit; qubitsRunner reads n back.
+func qubitsCircuit(t *testing.T, n int) circuit.Circuit {
+	b := builder.New(builder.Q(n), builder.C(n))
+	b.Measure(0, 0)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+	return c
+}
+
+func qubitsRunner(fail int) *mockOneShotRunner {
+	return newMockOneShotRunner(func(c circuit.Circuit, callNum int) (string, error) {
+		if c.Qubits() == fail {
+			return "", errors.New("boom")
+		}
+		return fmt.Sprint(c.Qubits()), nil
+	})
+}
+
+func TestGrid(t *testing.T) {
+	points := Grid(map[string][]float64{"b": {1, 2}, "a": {0, 0.5, 1}})
+	require.Len(t, points, 6)
+	assert.Equal(t, Params{"a": 0, "b": 1}, points[0])
+	assert.Equal(t, Params{"a": 0, "b": 2}, points[1])
+	assert.Equal(t, Params{"a": 1, "b": 2}, points[5])
+
+	assert.Equal(t, []Params{{}}, Grid(nil))
+	assert.Empty(t, Grid(map[string][]float64{"a": {1}, "b": nil}))
+}
+
+func TestSimulator_RunMany(t *testing.T) {
+	var circuits []circuit.Circuit
+	for n := 1; n <= 5; n++ {
+		circuits = append(circuits, qubitsCircuit(t, n))
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		runner := qubitsRunner(-1)
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 4, Runner: runner})
+		results := sim.RunMany(context.Background(), circuits)
+		require.Len(t, results, 5)
+		for i, res := range results {
+			require.NoError(t, res.Err)
+			assert.Equal(t, map[string]int{fmt.Sprint(i + 1): 100}, res.Counts)
+			assert.Nil(t, res.Params)
+		}
+		assert.Equal(t, 500, runner.CallCount())
+	})
+
+	t.Run("ItemError", func(t *testing.T) {
+		runner := qubitsRunner(3)
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 4, Runner: runner})
+		results := sim.RunMany(context.Background(), circuits)
+		for i, res := range results {
+			if i == 2 {
+				var pe *PartialRunError
+				require.ErrorAs(t, res.Err, &pe)
+				assert.EqualError(t, pe.Err, "circuit 2: boom")
+				continue
+			}
+			require.NoError(t, res.Err)
+			assert.Equal(t, 100, res.Counts[fmt.Sprint(i+1)])
+		}
+		assert.Less(t, runner.CallCount(), 500, "the failing circuit stops early")
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 2, Runner: qubitsRunner(-1)})
+		for _, res := range sim.RunMany(ctx, circuits) {
+			assert.ErrorIs(t, res.Err, context.Canceled)
+		}
+	})
+
+	t.Run("Pool", func(t *testing.T) {
+		pool := NewRunnerPool(func() OneShotRunner { return &countingRunner{} })
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 4, Pool: pool})
+		for _, res := range sim.RunMany(context.Background(), circuits) {
+			require.NoError(t, res.Err)
+		}
+		assert.LessOrEqual(t, pool.Size(), 4)
+		assert.Equal(t, int64(500), pool.Metrics().TotalExecutions)
+	})
+}
+
+func TestSimulator_Sweep(t *testing.T) {
+	tmpl := func(p Params) (circuit.Circuit, error) {
+		n := int(p["n"])
+		if n == 0 {
+			return nil, errors.New("no qubits")
+		}
+		return qubitsCircuit(t, n), nil
+	}
+	sim := NewSimulator(SimulatorOptions{Shots: 50, Workers: 3, Runner: qubitsRunner(-1)})
+	points := Grid(map[string][]float64{"n": {2, 0, 4}})
+
+	results := sim.Sweep(context.Background(), tmpl, points)
+	require.Len(t, results, 3)
+	assert.Equal(t, Params{"n": 2}, results[0].Params)
+	assert.Equal(t, map[string]int{"2": 50}, results[0].Counts)
+	assert.EqualError(t, results[1].Err, "building circuit: no qubits")
+	assert.Nil(t, results[1].Counts)
+	assert.Equal(t, map[string]int{"4": 50}, results[2].Counts)
+}
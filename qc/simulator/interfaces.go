@@ -78,6 +78,14 @@ type ValidatingRunner interface {
 	GetSupportedGates() []string
 }
 
+// PoolMember is implemented by runners that adapt to the RunnerPool they
+// are created by, e.g. by sharing CPU threads with its other runners.
+type PoolMember interface {
+	// JoinPool is called once by the pool that created the runner, before
+	// the runner is handed out. It must not call back into the pool.
+	JoinPool(p *RunnerPool)
+}
+
 // BatchRunner supports batch execution for better performance.
 type BatchRunner interface {
 	// RunBatch executes multiple shots efficiently.
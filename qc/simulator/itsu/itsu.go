@@ -197,7 +197,7 @@ func runOnce(sim *q.Q, c circuit.Circuit) (string, error) {
 			return "", fmt.Errorf("itsu: unsupported gate %s (op %d) encountered in runOnce", op.G.Name(), i)
 		}
 	}
-	// Return the final classical bit string (little-endian)
+	// Return the final classical bit string, clbit 0 first
 	return string(cbits), nil
 }
 
@@ -1,6 +1,7 @@
 package simulator
 
 import (
+	"context"
 	"runtime"
 	"sync"
 
@@ -9,6 +10,11 @@ import (
 
 // runParallelStatic  (static partition) – workers get equal shot counts, no channels.
 func (s *Simulator) RunParallelStatic(c circuit.Circuit) (map[string]int, error) {
+	hist, err := s.runParallelStatic(context.Background(), c)
+	return hist, legacyError(err)
+}
+
+func (s *Simulator) runParallelStatic(ctx context.Context, c circuit.Circuit) (map[string]int, error) {
 	shots := s.Shots
 	if shots <= 0 {
 		shots = 1024
@@ -32,10 +38,7 @@ func (s *Simulator) RunParallelStatic(c circuit.Circuit) (map[string]int, error)
 		Int("depth", c.Depth()).
 		Msg("itsu: Starting RunParallelStatic")
 
-	hist := make(map[string]int, shots)
-	var mu sync.Mutex
-	errChan := make(chan error, 1)
-
+	r := s.newRun(ctx, shots)
 	wg := sync.WaitGroup{}
 	for w := range workers {
 		cnt := per
@@ -45,45 +48,22 @@ func (s *Simulator) RunParallelStatic(c circuit.Circuit) (map[string]int, error)
 		wg.Add(1)
 		go func(n int) {
 			defer wg.Done()
+			runner := r.acquire()
+			defer r.release(runner)
 			for range n {
-				key, err := s.runner.RunOnce(c) // Run the circuit once
-
-				if err != nil {
-					select { // capture first error
-					case errChan <- err:
-					default:
-					}
+				if !r.shot(runner, c, func(err error) error { return err }) {
 					return
 				}
-				mu.Lock()
-				hist[key]++
-				mu.Unlock()
 			}
 		}(cnt)
 	}
-
 	wg.Wait()
-	close(errChan)
 
-	// Check if any errors were reported
-	var firstErr error
-	errCount := 0
-	for err := range errChan {
-		errCount++
-		if firstErr == nil {
-			firstErr = err // Capture the very first error reported
-		}
-		// Log additional errors if desired (as Warn or Error level)
-		if errCount > 1 {
-			s.log.Warn().Err(err).Int("error_count", errCount).Msg("itsu: Additional error reported")
-		}
-	}
-
-	if errCount > 0 {
-		s.log.Warn().Err(firstErr).Int("error_count", errCount).Msgf("itsu: Run finished with %d error(s)", errCount)
+	hist, err := r.finish(ctx)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("itsu: Run stopped")
 	} else {
 		s.log.Info().Int("shots", shots).Msg("itsu: Run finished successfully")
 	}
-
-	return hist, firstErr
+	return hist, err
 }
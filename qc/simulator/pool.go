@@ -0,0 +1,105 @@
+package simulator
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// RunnerPool hands out runner instances created by a RunnerFactory so that
+// every worker of a run has a runner of its own, and keeps them for later
+// runs. Runners taken from a pool need not be safe for concurrent use.
+type RunnerPool struct {
+	factory RunnerFactory
+
+	mu    sync.Mutex
+	idle  []OneShotRunner
+	all   []OneShotRunner
+	inUse int
+}
+
+// NewRunnerPool creates an empty pool; runners are created on demand.
+func NewRunnerPool(factory RunnerFactory) *RunnerPool {
+	return &RunnerPool{factory: factory}
+}
+
+// Get returns an idle runner or creates a new one. New runners that are a
+// PoolMember join the pool.
+func (p *RunnerPool) Get() (OneShotRunner, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := len(p.idle); n > 0 {
+		r := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.inUse++
+		return r, nil
+	}
+	r := p.factory()
+	if r == nil {
+		return nil, errors.New("runner factory returned nil")
+	}
+	if m, ok := r.(PoolMember); ok {
+		m.JoinPool(p)
+	}
+	p.all = append(p.all, r)
+	p.inUse++
+	return r, nil
+}
+
+// Put returns a runner obtained from Get to the pool.
+func (p *RunnerPool) Put(r OneShotRunner) {
+	p.mu.Lock()
+	p.idle = append(p.idle, r)
+	p.inUse--
+	p.mu.Unlock()
+}
+
+// InUse returns the number of runners taken from the pool and not yet put
+// back, e.g. the workers of the runs in progress.
+func (p *RunnerPool) InUse() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inUse
+}
+
+// Size returns the number of runners the pool has created.
+func (p *RunnerPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.all)
+}
+
+// Metrics merges the metrics of every runner in the pool that collects them.
+func (p *RunnerPool) Metrics() ExecutionMetrics {
+	p.mu.Lock()
+	runners := append([]OneShotRunner(nil), p.all...)
+	p.mu.Unlock()
+	var ms []ExecutionMetrics
+	for _, r := range runners {
+		if mc, ok := r.(MetricsCollector); ok {
+			ms = append(ms, mc.GetMetrics())
+		}
+	}
+	return MergeMetrics(ms...)
+}
+
+// MergeMetrics combines the metrics of several runners: counts and times add
+// up, the average is recomputed and the last error is that of the most
+// recent run.
+func MergeMetrics(ms ...ExecutionMetrics) ExecutionMetrics {
+	var out ExecutionMetrics
+	for _, m := range ms {
+		out.TotalExecutions += m.TotalExecutions
+		out.SuccessfulRuns += m.SuccessfulRuns
+		out.FailedRuns += m.FailedRuns
+		out.TotalTime += m.TotalTime
+		if m.LastRunTime.After(out.LastRunTime) {
+			out.LastRunTime = m.LastRunTime
+			out.LastError = m.LastError
+		}
+	}
+	if out.TotalExecutions > 0 {
+		out.AverageTime = out.TotalTime / time.Duration(out.TotalExecutions)
+	}
+	return out
+}
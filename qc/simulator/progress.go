@@ -0,0 +1,51 @@
+package simulator
+
+// Progress is a snapshot of a running simulation.
+type Progress struct {
+	Completed int            // shots finished so far
+	Total     int            // shots requested
+	Histogram map[string]int // partial histogram, owned by the receiver
+}
+
+// Done reports whether the snapshot is the final one of a successful run.
+func (p Progress) Done() bool { return p.Completed == p.Total }
+
+// ProgressFunc receives progress snapshots. Calls are serialised and their
+// Completed counts increase; the callback runs on a worker goroutine while
+// the histogram is locked, so it should return quickly.
+type ProgressFunc func(Progress)
+
+// progressReporter throttles snapshots to every n-th shot, always
+// including the last one.
+type progressReporter struct {
+	fn    ProgressFunc
+	every int
+	total int
+	done  int
+}
+
+func newProgressReporter(fn ProgressFunc, every, total int) *progressReporter {
+	if fn == nil {
+		return nil
+	}
+	if every <= 0 {
+		every = max(1, total/100)
+	}
+	return &progressReporter{fn: fn, every: every, total: total}
+}
+
+// shot counts one finished shot; the caller holds the histogram lock.
+func (p *progressReporter) shot(hist map[string]int) {
+	if p == nil {
+		return
+	}
+	p.done++
+	if p.done%p.every != 0 && p.done != p.total {
+		return
+	}
+	snapshot := make(map[string]int, len(hist))
+	for k, v := range hist {
+		snapshot[k] = v
+	}
+	p.fn(Progress{Completed: p.done, Total: p.total, Histogram: snapshot})
+}
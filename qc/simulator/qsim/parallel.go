@@ -0,0 +1,87 @@
+// Package qsim - Multi-threaded amplitude kernels
+package qsim
+
+import (
+	"runtime"
+	"sync"
+)
+
+const (
+	// DefaultParallelQubits is the smallest state that gate application and
+	// measurement split across threads by default. Below it the goroutine
+	// overhead outweighs the work, and shots are already run in parallel.
+	DefaultParallelQubits = 20
+
+	// minChunk is the fewest amplitudes a thread is given.
+	minChunk = 1 << 12
+)
+
+// kernelThreads returns the threads each of shots running shots gets when
+// they share threads.
+func kernelThreads(threads int, shots int64) int {
+	if shots <= 1 {
+		return threads
+	}
+	return max(1, threads/int(shots))
+}
+
+// defaultThreads is the kernel thread count of a new runner.
+func defaultThreads() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// threading is the goroutine budget of a state's kernels.
+type threading struct {
+	threads int // goroutines per kernel (<= 1 = serial)
+}
+
+// SetThreads sets the number of goroutines the state's kernels may use;
+// 1 or less runs them on the calling goroutine.
+func (t *threading) SetThreads(threads int) {
+	t.threads = threads
+}
+
+// split returns the number of chunks chunks cuts [0, n) into.
+func (t threading) split(n int) int {
+	return max(1, min(t.threads, n/minChunk))
+}
+
+// chunks splits [0, n) into contiguous ranges and calls fn for each, in
+// parallel when the state has threads to spare and n is large enough.
+// fn receives the index of its chunk, which is below split(n).
+func (t threading) chunks(n int, fn func(c, lo, hi int)) {
+	k := t.split(n)
+	if k == 1 {
+		fn(0, 0, n)
+		return
+	}
+	size := (n + k - 1) / k
+	var wg sync.WaitGroup
+	for c := range k {
+		lo, hi := c*size, min((c+1)*size, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(c, lo, hi)
+		}()
+	}
+	wg.Wait()
+}
+
+// parallel runs fn over the amplitude indices [0, n). Chunks must only write
+// amplitudes that no other index of [0, n) touches.
+func (t threading) parallel(n int, fn func(lo, hi int)) {
+	t.chunks(n, func(_, lo, hi int) { fn(lo, hi) })
+}
+
+// sum adds up fn over the chunks of [0, n), in chunk order so the result
+// does not depend on scheduling.
+func (t threading) sum(n int, fn func(lo, hi int) float64) float64 {
+	partial := make([]float64, t.split(n))
+	t.chunks(n, func(c, lo, hi int) { partial[c] = fn(lo, hi) })
+	var total float64
+	for _, s := range partial {
+		total += s
+	}
+	return total
+}
@@ -0,0 +1,230 @@
+package qsim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/gate"
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+// parallelQubits is large enough for the kernels to split into four chunks.
+const parallelQubits = 14
+
+func TestParallel_MatchesSerial(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for i := range 5 {
+		c := randomCircuit(t, rng, parallelQubits, 60)
+		for _, fuse := range []bool{true, false} {
+			p, err := compile(c, fuse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			serial := NewQuantumState(p.qubits, p.clbits)
+			threaded := NewQuantumState(p.qubits, p.clbits)
+			threaded.SetThreads(4)
+			for _, s := range []*QuantumState{serial, threaded} {
+				if err := p.run(context.Background(), s, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for k := range serial.amplitudes {
+				if cmplx.Abs(serial.amplitudes[k]-threaded.amplitudes[k]) > 1e-12 {
+					t.Fatalf("circuit %d (fuse=%v): amplitude %d is %v threaded, %v serial", i, fuse, k, threaded.amplitudes[k], serial.amplitudes[k])
+				}
+			}
+		}
+	}
+}
+
+func TestParallel_Measure(t *testing.T) {
+	b := builder.New(builder.Q(parallelQubits), builder.C(parallelQubits))
+	for q := range parallelQubits {
+		b.H(q)
+	}
+	c, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := compile(c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 20 {
+		state := NewQuantumState(p.qubits, p.clbits)
+		state.SetThreads(4)
+		if err := p.run(context.Background(), state, false); err != nil {
+			t.Fatal(err)
+		}
+		q := rand.Intn(parallelQubits)
+		result := state.Measure(q)
+
+		var norm float64
+		for i, amp := range state.amplitudes {
+			if (i>>q&1 == 1) != result && amp != 0 {
+				t.Fatalf("amplitude %d survived measuring qubit %d as %v", i, q, result)
+			}
+			norm += real(amp)*real(amp) + imag(amp)*imag(amp)
+		}
+		if math.Abs(norm-1) > 1e-9 {
+			t.Fatalf("norm after measurement is %v, want 1", norm)
+		}
+	}
+}
+
+func TestFredkin(t *testing.T) {
+	// |q2 q1 q0⟩ = |0 1 1⟩: control q0 set, so q1 and q2 swap
+	state := NewQuantumState(3, 0)
+	state.amplitudes[0], state.amplitudes[0b011] = 0, 1
+	if err := state.ApplyGate(gate.Fredkin(), []int{0, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if state.amplitudes[0b101] != 1 {
+		t.Errorf("Fredkin left amplitudes %v, want all weight on |101⟩", state.amplitudes)
+	}
+}
+
+func TestQSimRunner_Threads(t *testing.T) {
+	runner := NewQSimRunner()
+	if err := runner.Configure(map[string]interface{}{"threads": 3, "parallel_qubits": 10}); err != nil {
+		t.Fatal(err)
+	}
+	info := runner.GetBackendInfo()
+	if info.Metadata["threads"] != "3" || info.Metadata["parallel_qubits"] != "10" {
+		t.Errorf("metadata = %v, want threads 3 and parallel_qubits 10", info.Metadata)
+	}
+
+	for _, tc := range []struct {
+		qubits  int
+		shots   int64
+		share   bool
+		threads int
+	}{{9, 1, true, 1}, {10, 1, true, 3}, {10, 2, true, 1}, {10, 5, true, 1}, {10, 2, false, 3}} {
+		if err := runner.Configure(map[string]interface{}{"share_threads": tc.share}); err != nil {
+			t.Fatal(err)
+		}
+		p, err := compile(createSuperpositionCircuit(tc.qubits), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runner.running.Store(tc.shots)
+		if got := runner.newState(p).(*QuantumState).threads; got != tc.threads {
+			t.Errorf("%d-qubit state among %d shots (sharing %v) has %d threads, want %d", tc.qubits, tc.shots, tc.share, got, tc.threads)
+		}
+	}
+	runner.running.Store(0)
+	if got := kernelThreads(8, 2); got != 4 {
+		t.Errorf("two shots share 8 threads as %d each, want 4", got)
+	}
+
+	// Pooled runners share with the other runners taken from their pool
+	pool := simulator.NewRunnerPool(func() simulator.OneShotRunner {
+		r := NewQSimRunner()
+		if err := r.Configure(map[string]interface{}{"threads": 4, "parallel_qubits": 10}); err != nil {
+			t.Error(err)
+		}
+		return r
+	})
+	first, _ := pool.Get()
+	second, _ := pool.Get()
+	p, err := compile(createSuperpositionCircuit(10), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := first.(*QSimRunner).newState(p).(*QuantumState).threads; got != 2 {
+		t.Errorf("one of two pooled runners has %d threads, want 2", got)
+	}
+	pool.Put(second)
+	if got := first.(*QSimRunner).newState(p).(*QuantumState).threads; got != 4 {
+		t.Errorf("the only pooled runner in use has %d threads, want 4", got)
+	}
+	pool.Put(first)
+
+	for _, opts := range []map[string]interface{}{
+		{"threads": 0},
+		{"threads": "4"},
+		{"parallel_qubits": -1},
+		{"share_threads": "yes"},
+	} {
+		if err := runner.Configure(opts); err == nil {
+			t.Errorf("Configure(%v) should fail", opts)
+		}
+	}
+}
+
+// layerCircuit applies a layer of Hadamards and a CNOT chain, so every
+// kernel sweeps the whole state.
+func layerCircuit(tb testing.TB, qubits int) circuit.Circuit {
+	b := builder.New(builder.Q(qubits), builder.C(qubits))
+	for q := range qubits {
+		b.H(q)
+	}
+	for q := 0; q+1 < qubits; q++ {
+		b.CNOT(q, q+1)
+	}
+	for q := range qubits {
+		b.Measure(q, q)
+	}
+	c, err := b.BuildCircuit()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+func BenchmarkQSimRunner_Threads(b *testing.B) {
+	threadCounts := []int{1}
+	if n := runtime.GOMAXPROCS(0); n > 1 {
+		threadCounts = append(threadCounts, n)
+	}
+	for _, qubits := range []int{16, 20, 22} {
+		c := layerCircuit(b, qubits)
+		for _, threads := range threadCounts {
+			b.Run(fmt.Sprintf("%dq/%dthreads", qubits, threads), func(b *testing.B) {
+				runner := NewQSimRunner()
+				if err := runner.Configure(map[string]interface{}{"threads": threads, "parallel_qubits": 1}); err != nil {
+					b.Fatal(err)
+				}
+				for i := 0; i < b.N; i++ {
+					if _, err := runner.RunOnce(c); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkQSimRunner_SharedThreads runs one 20-qubit shot per core through
+// a Simulator sharing one runner, with the kernel threads split among the
+// running shots and with every shot taking all of them.
+func BenchmarkQSimRunner_SharedThreads(b *testing.B) {
+	workers := runtime.GOMAXPROCS(0)
+	c := layerCircuit(b, DefaultParallelQubits)
+	for _, shared := range []bool{true, false} {
+		name := "Shared"
+		if !shared {
+			name = "PerShot"
+		}
+		b.Run(name, func(b *testing.B) {
+			runner := NewQSimRunner()
+			if err := runner.Configure(map[string]interface{}{"share_threads": shared}); err != nil {
+				b.Fatal(err)
+			}
+			sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: 2 * workers, Workers: workers, Runner: runner})
+			for i := 0; i < b.N; i++ {
+				if _, err := sim.Run(c); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
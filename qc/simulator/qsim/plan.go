@@ -0,0 +1,492 @@
+// Package qsim - Compiled execution plans
+package qsim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/gate"
+)
+
+// planCacheSize bounds the number of plans a runner keeps.
+const planCacheSize = 64
+
+type (
+	// plan is a circuit compiled for replay: every shot runs its kernels
+	// in order without looking at gate names again. Runs of single-qubit
+	// gates are fused into one 2x2 matrix, and gates acting on the same
+	// pair of qubits into one 4x4 matrix.
+	plan struct {
+		qubits int
+		clbits int
+		steps  []kernel
+	}
+
+	kernel struct {
+		kind   kernelKind
+		q0, q1 int            // target qubits; for kernelU2 q0 is the low bit of the matrix index
+		m1     [4]complex128  // kernelU1, row-major
+		m2     [16]complex128 // kernelU2, row-major, index = b(q0) + 2*b(q1)
+		gate   gate.Gate      // kernelGate
+		qubits []int          // kernelGate
+		cbit   int            // kernelMeasure (-1 = discard)
+	}
+
+	kernelKind int
+
+	// planCache maps circuits to their plans by identity; circuits are
+	// immutable, so a plan stays valid as long as its circuit lives. When
+	// full, it evicts the least recently used plan.
+	planCache struct {
+		mu    sync.Mutex
+		plans map[planKey]*planEntry
+		clock uint64 // ticks on every lookup
+		gen   uint64 // bumped by reset
+	}
+
+	// planKey identifies a plan: the same circuit compiles differently
+	// with and without fusion.
+	planKey struct {
+		c    circuit.Circuit
+		fuse bool
+	}
+
+	planEntry struct {
+		p    *plan
+		used uint64 // clock of the latest lookup
+	}
+)
+
+const (
+	kernelU1 kernelKind = iota
+	kernelU2
+	kernelGate // a gate on three qubits, applied natively
+	kernelMeasure
+)
+
+// get returns the cached plan of c, compiling it on a miss. Circuits of a
+// type that cannot be a map key are compiled every time. A plan compiled
+// while the cache was reset is returned but not kept.
+func (pc *planCache) get(c circuit.Circuit, fuse bool) (*plan, error) {
+	if !reflect.TypeOf(c).Comparable() {
+		return compile(c, fuse)
+	}
+	key := planKey{c, fuse}
+	pc.mu.Lock()
+	pc.clock++
+	e, ok := pc.plans[key]
+	if ok {
+		e.used = pc.clock
+	}
+	gen := pc.gen
+	pc.mu.Unlock()
+	if ok {
+		return e.p, nil
+	}
+	p, err := compile(c, fuse)
+	if err != nil {
+		return nil, err
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.gen != gen {
+		return p, nil
+	}
+	if pc.plans == nil {
+		pc.plans = make(map[planKey]*planEntry)
+	}
+	if _, ok := pc.plans[key]; !ok && len(pc.plans) >= planCacheSize {
+		pc.evict()
+	}
+	pc.plans[key] = &planEntry{p: p, used: pc.clock}
+	return p, nil
+}
+
+// evict drops the least recently used plan; pc.mu must be held.
+func (pc *planCache) evict() {
+	var oldest planKey
+	first := true
+	for k, e := range pc.plans {
+		if first || e.used < pc.plans[oldest].used {
+			oldest, first = k, false
+		}
+	}
+	delete(pc.plans, oldest)
+}
+
+// reset drops every cached plan, including those being compiled.
+func (pc *planCache) reset() {
+	pc.mu.Lock()
+	pc.plans = nil
+	pc.gen++
+	pc.mu.Unlock()
+}
+
+// compile turns the operations of c into kernels. Without fuse every gate
+// becomes a kernel of its own.
+func compile(c circuit.Circuit, fuse bool) (*plan, error) {
+	p := &plan{qubits: c.Qubits(), clbits: c.Clbits()}
+	// pending holds the fused single-qubit gates not yet emitted, last
+	// the index of the latest kernel touching each qubit (-1 = none)
+	pending := make([]*[4]complex128, c.Qubits())
+	last := make([]int, c.Qubits())
+	for i := range last {
+		last[i] = -1
+	}
+	touch := func(qubits ...int) {
+		for _, q := range qubits {
+			last[q] = len(p.steps) - 1
+		}
+	}
+	// flush emits the pending gates of q; when fusing, it folds them into
+	// the latest kernel on q if that is a 4x4 block: nothing has touched q
+	// since.
+	flush := func(q int) {
+		m := pending[q]
+		if m == nil {
+			return
+		}
+		pending[q] = nil
+		if k := last[q]; fuse && k >= 0 && p.steps[k].kind == kernelU2 {
+			s := &p.steps[k]
+			s.m2 = mul4(embed(*m, q == s.q1), s.m2)
+			return
+		}
+		p.steps = append(p.steps, kernel{kind: kernelU1, q0: q, m1: *m})
+		touch(q)
+	}
+
+	for _, op := range c.Operations() {
+		for _, q := range op.Qubits {
+			if q < 0 || q >= p.qubits {
+				return nil, fmt.Errorf("invalid qubit %d for %d-qubit system", q, p.qubits)
+			}
+		}
+		name := op.G.Name()
+		switch {
+		case name == "MEASURE":
+			if len(op.Qubits) != 1 {
+				return nil, fmt.Errorf("measurement requires exactly one qubit, got %d", len(op.Qubits))
+			}
+			q := op.Qubits[0]
+			flush(q)
+			cbit := op.Cbit
+			if cbit >= p.clbits {
+				cbit = -1
+			}
+			p.steps = append(p.steps, kernel{kind: kernelMeasure, q0: q, cbit: cbit})
+			touch(q)
+
+		case len(op.Qubits) == 1:
+			m, ok := matrix1(name)
+			if !ok {
+				return nil, fmt.Errorf("failed to apply gate %s: unsupported gate: %s", name, name)
+			}
+			q := op.Qubits[0]
+			if pending[q] != nil {
+				m = mul2(m, *pending[q])
+			}
+			pending[q] = &m
+			if !fuse {
+				flush(q)
+			}
+
+		case len(op.Qubits) == 2:
+			m, ok := matrix2(name)
+			if !ok {
+				return nil, fmt.Errorf("failed to apply gate %s: unsupported gate: %s", name, name)
+			}
+			a, b := op.Qubits[0], op.Qubits[1]
+			if fuse {
+				if pa := pending[a]; pa != nil {
+					m = mul4(m, embed(*pa, false))
+					pending[a] = nil
+				}
+				if pb := pending[b]; pb != nil {
+					m = mul4(m, embed(*pb, true))
+					pending[b] = nil
+				}
+				if k := last[a]; k >= 0 && k == last[b] && p.steps[k].kind == kernelU2 {
+					s := &p.steps[k]
+					if s.q0 != a {
+						m = swapQubits(m)
+					}
+					s.m2 = mul4(m, s.m2)
+					continue
+				}
+			}
+			p.steps = append(p.steps, kernel{kind: kernelU2, q0: a, q1: b, m2: m})
+			touch(a, b)
+
+		default:
+			if len(op.Qubits) != 3 || (name != "TOFFOLI" && name != "FREDKIN") {
+				return nil, fmt.Errorf("failed to apply gate %s: unsupported gate: %s", name, name)
+			}
+			for _, q := range op.Qubits {
+				flush(q)
+			}
+			p.steps = append(p.steps, kernel{kind: kernelGate, gate: op.G, qubits: op.Qubits})
+			touch(op.Qubits...)
+		}
+	}
+	for q := range pending {
+		flush(q)
+	}
+	return p, nil
+}
+
+// run replays the plan on state. Measurements are skipped unless measure is
+// set; ctx is checked before every kernel.
+func (p *plan) run(ctx context.Context, state stateVector, measure bool) error {
+	for i := range p.steps {
+		if ctx.Done() != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+		k := &p.steps[i]
+		switch k.kind {
+		case kernelU1:
+			state.applyMatrix1(k.q0, &k.m1)
+		case kernelU2:
+			state.applyMatrix2(k.q0, k.q1, &k.m2)
+		case kernelGate:
+			if err := state.ApplyGate(k.gate, k.qubits); err != nil {
+				return fmt.Errorf("failed to apply gate %s: %w", k.gate.Name(), err)
+			}
+		case kernelMeasure:
+			if !measure {
+				continue
+			}
+			result := state.Measure(k.q0)
+			if k.cbit >= 0 {
+				state.clbits()[k.cbit] = result
+			}
+		}
+	}
+	return nil
+}
+
+// amplitude is the element type of a state vector.
+type amplitude interface {
+	complex64 | complex128
+}
+
+// applyMatrix1 applies a 2x2 unitary to qubit q.
+func (qs *QuantumState) applyMatrix1(q int, m *[4]complex128) {
+	applyMatrix1(qs.threading, qs.amplitudes, q, m)
+}
+
+// applyMatrix2 applies a 4x4 unitary to qubits q0 (low bit of the matrix
+// index) and q1.
+func (qs *QuantumState) applyMatrix2(q0, q1 int, m *[16]complex128) {
+	applyMatrix2(qs.threading, qs.amplitudes, q0, q1, m)
+}
+
+// applyMatrix1 applies a 2x2 unitary to qubit q of amps. The kernel runs
+// over the pairs (i, i|mask): pair k is k with a zero bit inserted at q.
+func applyMatrix1[T amplitude](t threading, amps []T, q int, m *[4]complex128) {
+	mask := 1 << q
+	m0, m1, m2, m3 := T(m[0]), T(m[1]), T(m[2]), T(m[3])
+	t.parallel(len(amps)/2, func(lo, hi int) {
+		for k := lo; k < hi; k++ {
+			i := (k>>q)<<(q+1) | k&(mask-1)
+			j := i | mask
+			a0, a1 := amps[i], amps[j]
+			amps[i] = m0*a0 + m1*a1
+			amps[j] = m2*a0 + m3*a1
+		}
+	})
+}
+
+// applyMatrix2 applies a 4x4 unitary to qubits q0 and q1 of amps.
+func applyMatrix2[T amplitude](t threading, amps []T, q0, q1 int, m *[16]complex128) {
+	var mt [16]T
+	for i, v := range m {
+		mt[i] = T(v)
+	}
+	m0, m1 := 1<<q0, 1<<q1
+	t.parallel(len(amps), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if i&(m0|m1) != 0 {
+				continue
+			}
+			idx := [4]int{i, i | m0, i | m1, i | m0 | m1}
+			a := [4]T{amps[idx[0]], amps[idx[1]], amps[idx[2]], amps[idx[3]]}
+			for r := range 4 {
+				amps[idx[r]] = mt[4*r]*a[0] + mt[4*r+1]*a[1] + mt[4*r+2]*a[2] + mt[4*r+3]*a[3]
+			}
+		}
+	})
+}
+
+// swapWhere swaps every amplitude i with i^flip for which i&mask == want;
+// want must pick one side of each pair, so permutation gates such as
+// TOFFOLI and FREDKIN visit each pair once.
+func swapWhere[T amplitude](t threading, amps []T, mask, want, flip int) {
+	t.parallel(len(amps), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if i&mask == want {
+				j := i ^ flip
+				amps[i], amps[j] = amps[j], amps[i]
+			}
+		}
+	})
+}
+
+// norm2 returns |a|², accumulated in double precision.
+func norm2[T amplitude](a T) float64 {
+	c := complex128(a)
+	return real(c)*real(c) + imag(c)*imag(c)
+}
+
+// measure measures qubit q of amps and collapses them onto the result.
+func measure[T amplitude](t threading, amps []T, qubit int) bool {
+	// Weigh both outcomes in one pass; the state may drift from unit norm,
+	// so the kept half is renormalized by its own weight
+	mask := 1 << qubit
+	n := len(amps)
+	weights := make([][2]float64, t.split(n))
+	t.chunks(n, func(c, lo, hi int) {
+		var w [2]float64
+		for i := lo; i < hi; i++ {
+			w[(i>>qubit)&1] += norm2(amps[i])
+		}
+		weights[c] = w
+	})
+	var probZero, probOne float64
+	for _, w := range weights {
+		probZero += w[0]
+		probOne += w[1]
+	}
+
+	// Perform measurement
+	result := rand.Float64() < probOne
+
+	// Collapse the state: zero the amplitudes that disagree with the result
+	// and renormalize the rest
+	keep := probZero
+	if result {
+		keep = probOne
+	}
+	invNorm := T(complex(1, 0))
+	if keep > 1e-10 {
+		invNorm = T(complex(1/math.Sqrt(keep), 0))
+	}
+	t.parallel(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&mask != 0) == result {
+				amps[i] *= invNorm
+			} else {
+				amps[i] = 0
+			}
+		}
+	})
+
+	return result
+}
+
+// probabilities returns |a|² for every amplitude.
+func probabilities[T amplitude](t threading, amps []T) []float64 {
+	probs := make([]float64, len(amps))
+	t.parallel(len(amps), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			probs[i] = norm2(amps[i])
+		}
+	})
+	return probs
+}
+
+// matrix1 returns the matrix of a single-qubit gate.
+func matrix1(name string) ([4]complex128, bool) {
+	h := complex(1/math.Sqrt2, 0)
+	switch name {
+	case "H":
+		return [4]complex128{h, h, h, -h}, true
+	case "X":
+		return [4]complex128{0, 1, 1, 0}, true
+	case "Y":
+		return [4]complex128{0, -1i, 1i, 0}, true
+	case "Z":
+		return [4]complex128{1, 0, 0, -1}, true
+	case "S":
+		return [4]complex128{1, 0, 0, 1i}, true
+	}
+	return [4]complex128{}, false
+}
+
+// matrix2 returns the matrix of a two-qubit gate on (qubits[0], qubits[1]).
+func matrix2(name string) ([16]complex128, bool) {
+	var m [16]complex128
+	switch name {
+	case "CNOT": // control q0: |1,t⟩ → |1,¬t⟩
+		m[0], m[4*3+1], m[4*2+2], m[4*1+3] = 1, 1, 1, 1
+	case "CZ":
+		m[0], m[5], m[10], m[15] = 1, 1, 1, -1
+	case "SWAP":
+		m[0], m[4*2+1], m[4*1+2], m[15] = 1, 1, 1, 1
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// mul2 returns a·b for 2x2 matrices.
+func mul2(a, b [4]complex128) [4]complex128 {
+	return [4]complex128{
+		a[0]*b[0] + a[1]*b[2], a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2], a[2]*b[1] + a[3]*b[3],
+	}
+}
+
+// mul4 returns a·b for 4x4 matrices.
+func mul4(a, b [16]complex128) [16]complex128 {
+	var out [16]complex128
+	for r := range 4 {
+		for c := range 4 {
+			var sum complex128
+			for k := range 4 {
+				sum += a[4*r+k] * b[4*k+c]
+			}
+			out[4*r+c] = sum
+		}
+	}
+	return out
+}
+
+// embed lifts a single-qubit matrix onto the low (high = false) or high
+// qubit of a 4x4 block.
+func embed(m [4]complex128, high bool) [16]complex128 {
+	var out [16]complex128
+	for r := range 4 {
+		for c := range 4 {
+			lr, lc, hr, hc := r&1, c&1, r>>1, c>>1
+			if high {
+				lr, lc, hr, hc = hr, hc, lr, lc
+			}
+			if hr == hc {
+				out[4*r+c] = m[2*lr+lc]
+			}
+		}
+	}
+	return out
+}
+
+// swapQubits exchanges the roles of the two qubits of a 4x4 matrix.
+func swapQubits(m [16]complex128) [16]complex128 {
+	perm := [4]int{0, 2, 1, 3}
+	var out [16]complex128
+	for r := range 4 {
+		for c := range 4 {
+			out[4*perm[r]+perm[c]] = m[4*r+c]
+		}
+	}
+	return out
+}
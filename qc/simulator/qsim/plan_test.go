@@ -0,0 +1,191 @@
+package qsim
+
+import (
+	"context"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// randomCircuit builds a measurement-free circuit from every gate qsim knows.
+func randomCircuit(t testing.TB, rng *rand.Rand, qubits, gates int) circuit.Circuit {
+	b := builder.New(builder.Q(qubits), builder.C(qubits))
+	for range gates {
+		q := rng.Perm(qubits)
+		switch rng.Intn(10) {
+		case 0:
+			b.H(q[0])
+		case 1:
+			b.X(q[0])
+		case 2:
+			b.Y(q[0])
+		case 3:
+			b.Z(q[0])
+		case 4:
+			b.S(q[0])
+		case 5:
+			b.CNOT(q[0], q[1])
+		case 6:
+			b.CZ(q[0], q[1])
+		case 7:
+			b.SWAP(q[0], q[1])
+		case 8:
+			b.Toffoli(q[0], q[1], q[2])
+		case 9:
+			b.Fredkin(q[0], q[1], q[2])
+		}
+	}
+	c, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatalf("building circuit: %v", err)
+	}
+	return c
+}
+
+// referenceState applies the gates one by one, without a plan.
+func referenceState(t testing.TB, c circuit.Circuit) []complex128 {
+	state := NewQuantumState(c.Qubits(), c.Clbits())
+	for _, op := range c.Operations() {
+		if err := state.ApplyGate(op.G, op.Qubits); err != nil {
+			t.Fatalf("applying %s: %v", op.G.Name(), err)
+		}
+	}
+	return state.Amplitudes()
+}
+
+func TestPlan_MatchesReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range 50 {
+		c := randomCircuit(t, rng, 3+i%4, 40)
+		want := referenceState(t, c)
+		for _, fuse := range []bool{true, false} {
+			p, err := compile(c, fuse)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			state := NewQuantumState(p.qubits, p.clbits)
+			if err := p.run(context.Background(), state, false); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			for k, amp := range state.Amplitudes() {
+				if cmplx.Abs(amp-want[k]) > 1e-12 {
+					t.Fatalf("circuit %d (fuse=%v): amplitude %d is %v, want %v", i, fuse, k, amp, want[k])
+				}
+			}
+		}
+	}
+}
+
+func TestPlan_Fusion(t *testing.T) {
+	b := builder.New(builder.Q(3), builder.C(3))
+	b.H(0).X(0).Z(0).S(1) // fused into the CNOT block
+	b.CNOT(0, 1).CZ(1, 0) // one 4x4 block, either qubit order
+	b.H(1).Y(2).H(2)      // H(1) folds into the block, Y H on qubit 2 fuse
+	b.Toffoli(0, 1, 2)    // applied natively
+	b.Measure(0, 0).H(0)  // a measurement ends fusion on its qubit
+	c, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fused, err := compile(c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make([]kernelKind, len(fused.steps))
+	for i, k := range fused.steps {
+		kinds[i] = k.kind
+	}
+	want := []kernelKind{kernelU2, kernelU1, kernelGate, kernelMeasure, kernelU1}
+	if len(kinds) != len(want) {
+		t.Fatalf("kernels = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("kernels = %v, want %v", kinds, want)
+		}
+	}
+
+	plain, err := compile(c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain.steps) != len(c.Operations()) {
+		t.Errorf("unfused plan has %d kernels, want one per operation (%d)", len(plain.steps), len(c.Operations()))
+	}
+}
+
+func TestPlan_Cache(t *testing.T) {
+	runner := NewQSimRunner()
+	c := createBellStateCircuit()
+	p1, err := runner.plan(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, _ := runner.plan(c)
+	if p1 != p2 {
+		t.Error("repeated shots should reuse the cached plan")
+	}
+
+	if err := runner.Configure(map[string]interface{}{"fusion": false}); err != nil {
+		t.Fatal(err)
+	}
+	p3, _ := runner.plan(c)
+	if p3 == p1 {
+		t.Error("changing fusion should drop cached plans")
+	}
+	if err := runner.Configure(map[string]interface{}{"fusion": "yes"}); err == nil {
+		t.Error("expected an error for a non-bool fusion option")
+	}
+
+	var cache planCache
+	fused, _ := cache.get(c, true)
+	unfused, _ := cache.get(c, false)
+	if fused == unfused {
+		t.Error("plans with and without fusion should be cached apart")
+	}
+
+	// The cache evicts the least recently used plan, not every plan
+	for range planCacheSize + 1 {
+		cache.get(createHadamardCircuit(), true)
+		if p, _ := cache.get(c, true); p != fused {
+			t.Fatal("a plan in use should stay cached")
+		}
+	}
+	cache.mu.Lock()
+	n := len(cache.plans)
+	cache.mu.Unlock()
+	if n != planCacheSize {
+		t.Errorf("cache holds %d plans, want the limit %d", n, planCacheSize)
+	}
+}
+
+func BenchmarkQSimRunner_Fusion(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	c := randomCircuit(b, rng, 10, 200)
+	for _, fuse := range []bool{true, false} {
+		name := "Fused"
+		if !fuse {
+			name = "Unfused"
+		}
+		b.Run(name, func(b *testing.B) {
+			runner := NewQSimRunner()
+			if err := runner.Configure(map[string]interface{}{"fusion": fuse}); err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < b.N; i++ {
+				if _, err := runner.RunOnce(c); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	b.Run("Reference", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			referenceState(b, c)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package qsim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+)
+
+// singleTolerance bounds the error of a single-precision amplitude after a
+// few dozen gates.
+const singleTolerance = 1e-5
+
+func TestPrecision_MatchesDouble(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for i := range 30 {
+		c := randomCircuit(t, rng, 3+i%6, 60)
+		want := referenceState(t, c)
+
+		// The plan path (fused kernels) and the gate-by-gate path
+		p, err := compile(c, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planned := NewQuantumState64(p.qubits, p.clbits)
+		if err := p.run(context.Background(), planned, false); err != nil {
+			t.Fatal(err)
+		}
+		direct := NewQuantumState64(c.Qubits(), c.Clbits())
+		for _, op := range c.Operations() {
+			if err := direct.ApplyGate(op.G, op.Qubits); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for name, state := range map[string]*QuantumState64{"plan": planned, "gates": direct} {
+			for k, amp := range state.Amplitudes() {
+				if cmplx.Abs(amp-want[k]) > singleTolerance {
+					t.Fatalf("circuit %d (%s): amplitude %d is %v, want %v", i, name, k, amp, want[k])
+				}
+			}
+		}
+	}
+}
+
+func TestQSimRunner_Precision(t *testing.T) {
+	runner := NewQSimRunner()
+	if got := runner.GetBackendInfo().Metadata["precision"]; got != PrecisionDouble {
+		t.Errorf("default precision = %q, want %q", got, PrecisionDouble)
+	}
+	if err := runner.Configure(map[string]interface{}{"precision": PrecisionSingle}); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.GetBackendInfo().Metadata["precision"]; got != PrecisionSingle {
+		t.Errorf("precision = %q, want %q", got, PrecisionSingle)
+	}
+
+	bell := createBellStateCircuit()
+	amps, err := runner.StateVector(bell)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := 1 / math.Sqrt2
+	for k, want := range []complex128{complex(h, 0), 0, 0, complex(h, 0)} {
+		if cmplx.Abs(amps[k]-want) > singleTolerance {
+			t.Errorf("amplitude %d = %v, want %v", k, amps[k], want)
+		}
+	}
+	for range 100 {
+		result, err := runner.RunOnce(bell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != "00" && result != "11" {
+			t.Fatalf("Bell state measured %q", result)
+		}
+	}
+
+	// One more qubit fits in the same memory
+	b := builder.New(builder.Q(MaxQubits+1), builder.C(1))
+	big, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.ValidateCircuit(big); err != nil {
+		t.Errorf("single precision should accept %d qubits: %v", MaxQubits+1, err)
+	}
+	if err := NewQSimRunner().ValidateCircuit(big); err == nil {
+		t.Errorf("double precision should reject %d qubits", MaxQubits+1)
+	}
+
+	for _, v := range []interface{}{"half", 32} {
+		if err := runner.Configure(map[string]interface{}{"precision": v}); err == nil {
+			t.Errorf("precision %v should be rejected", v)
+		}
+	}
+}
+
+func BenchmarkQSimRunner_PrecisionMemory(b *testing.B) {
+	for _, qubits := range []int{16, 20} {
+		c := layerCircuit(b, qubits)
+		for _, precision := range []string{PrecisionDouble, PrecisionSingle} {
+			b.Run(fmt.Sprintf("%dq/%s", qubits, precision), func(b *testing.B) {
+				runner := NewQSimRunner()
+				if err := runner.Configure(map[string]interface{}{"precision": precision}); err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := runner.RunOnce(c); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
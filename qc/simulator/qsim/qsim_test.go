@@ -305,7 +305,7 @@ func TestQSimRunner_GateImplementations(t *testing.T) {
 				c, _ := b.BuildCircuit()
 				return c
 			},
-			expected: map[string]float64{"10": 1.0}, // |1⟩|0⟩ becomes |0⟩|1⟩
+			expected: map[string]float64{"01": 1.0}, // |1⟩|0⟩ becomes |0⟩|1⟩
 		},
 	}
 
@@ -405,7 +405,7 @@ func TestQSimRunner_ErrorHandling(t *testing.T) {
 	runner := NewQSimRunner()
 
 	// Test invalid circuit with too many qubits
-	b := builder.New(builder.Q(25), builder.C(25)) // Exceeds limit of 20
+	b := builder.New(builder.Q(MaxQubits+1), builder.C(MaxQubits+1)) // Exceeds MaxQubits
 	invalidCirc, _ := b.BuildCircuit()
 
 	err := runner.ValidateCircuit(invalidCirc)
@@ -459,3 +459,69 @@ func BenchmarkQSimRunner_vs_Itsubaki(b *testing.B) {
 		}
 	})
 }
+
+func TestQSimRunner_StateVector(t *testing.T) {
+	runner := NewQSimRunner()
+
+	// Measurements are left out, so the Bell state is returned intact
+	amps, err := runner.StateVector(createBellStateCircuit())
+	if err != nil {
+		t.Fatalf("StateVector failed: %v", err)
+	}
+	if len(amps) != 4 {
+		t.Fatalf("Expected 4 amplitudes, got %d", len(amps))
+	}
+
+	want := []complex128{complex(1/math.Sqrt2, 0), 0, 0, complex(1/math.Sqrt2, 0)}
+	for i := range want {
+		if math.Abs(real(amps[i])-real(want[i])) > 1e-10 || math.Abs(imag(amps[i])-imag(want[i])) > 1e-10 {
+			t.Errorf("Amplitude %d: expected %v, got %v", i, want[i], amps[i])
+		}
+	}
+
+	// X on qubit 1 sets bit 1 of the index
+	b := builder.New(builder.Q(2))
+	b.X(1)
+	circ, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatalf("Failed to build circuit: %v", err)
+	}
+	amps, err = runner.StateVector(circ)
+	if err != nil {
+		t.Fatalf("StateVector failed: %v", err)
+	}
+	if amps[2] != 1 {
+		t.Errorf("Expected |q1=1⟩ at index 2, got %v", amps)
+	}
+}
+
+// TestBitOrder checks that every backend reports clbit 0 first
+func TestBitOrder(t *testing.T) {
+	b := builder.New(builder.Q(3), builder.C(3))
+	b.X(0)
+	b.Measure(0, 2) // q0 → c2
+	b.Measure(1, 0)
+	b.Measure(2, 1)
+	c, err := b.BuildCircuit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"itsu", "qsim"} {
+		runner, err := simulator.CreateRunner(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: 10, Runner: runner, Backend: name})
+		res, err := sim.RunResult(c)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if res.Counts["001"] != 10 || res.Order != simulator.Clbit0First || res.Backend != name {
+			t.Errorf("%s: got %+v, want 10 × 001 in clbit0-first order", name, res)
+		}
+		if got := res.IntCounts(); got[4] != 10 {
+			t.Errorf("%s: int counts %v, want 10 × 4", name, got)
+		}
+	}
+}
@@ -4,6 +4,7 @@ package qsim
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +14,10 @@ import (
 	"github.com/kegliz/qplay/qc/simulator"
 )
 
+// MaxQubits is the largest circuit ValidateCircuit accepts in double
+// precision; its state takes 256 MiB. Single precision allows one more.
+const MaxQubits = 24
+
 // Supported gates for the QSim backend
 var supportedGates = []string{
 	"H", "X", "Y", "Z", "S", "CNOT", "CZ", "SWAP", "TOFFOLI", "FREDKIN", "MEASURE",
@@ -43,48 +48,28 @@ func (r *QSimRunner) RunOnceWithContext(ctx context.Context, c circuit.Circuit)
 	default:
 	}
 
-	// Initialize quantum state
-	state := NewQuantumState(c.Qubits(), c.Clbits())
-
-	// Execute circuit operations
-	for _, op := range c.Operations() {
-		// Check context cancellation during execution
-		select {
-		case <-ctx.Done():
-			r.metrics.failedRuns.Add(1)
+	// Replay the compiled plan of the circuit on a fresh state
+	p, err := r.plan(c)
+	if err != nil {
+		r.metrics.failedRuns.Add(1)
+		r.metrics.lastError.Store(err.Error())
+		return "", err
+	}
+	r.running.Add(1)
+	defer r.running.Add(-1)
+	state := r.newState(p)
+	if err := p.run(ctx, state, true); err != nil {
+		r.metrics.failedRuns.Add(1)
+		if ctx.Err() != nil {
 			r.metrics.lastError.Store("context cancelled during execution")
-			return "", ctx.Err()
-		default:
-		}
-
-		if op.G.Name() == "MEASURE" {
-			// Perform measurement
-			if len(op.Qubits) != 1 {
-				err := fmt.Errorf("measurement requires exactly one qubit, got %d", len(op.Qubits))
-				r.metrics.failedRuns.Add(1)
-				r.metrics.lastError.Store(err.Error())
-				return "", err
-			}
-
-			qubit := op.Qubits[0]
-			result := state.Measure(qubit)
-
-			// Store classical bit if specified
-			if op.Cbit >= 0 && op.Cbit < len(state.classicalBits) {
-				state.classicalBits[op.Cbit] = result
-			}
 		} else {
-			// Apply quantum gate
-			if err := state.ApplyGate(op.G, op.Qubits); err != nil {
-				r.metrics.failedRuns.Add(1)
-				r.metrics.lastError.Store(err.Error())
-				return "", fmt.Errorf("failed to apply gate %s: %w", op.G.Name(), err)
-			}
+			r.metrics.lastError.Store(err.Error())
 		}
+		return "", err
 	}
 
 	// Convert classical bits to result string
-	result := r.formatResult(state.classicalBits)
+	result := r.formatResult(state.clbits())
 
 	r.metrics.successfulRuns.Add(1)
 	r.metrics.lastError.Store("")
@@ -96,14 +81,15 @@ func (r *QSimRunner) RunOnceWithContext(ctx context.Context, c circuit.Circuit)
 	return result, nil
 }
 
-// formatResult converts classical bits to string representation
+// formatResult converts classical bits to a bitstring with clbit 0 first
+// (simulator.Clbit0First)
 func (r *QSimRunner) formatResult(bits []bool) string {
 	if len(bits) == 0 {
 		return "0" // Default result for circuits without measurements
 	}
 
 	var result strings.Builder
-	for i := len(bits) - 1; i >= 0; i-- { // MSB first
+	for i := range bits {
 		if bits[i] {
 			result.WriteByte('1')
 		} else {
@@ -115,6 +101,10 @@ func (r *QSimRunner) formatResult(bits []bool) string {
 
 // BackendProvider implementation
 func (r *QSimRunner) GetBackendInfo() simulator.BackendInfo {
+	r.mu.RLock()
+	threads, parallelQubits, precision := r.threads, r.parallelQubits, r.precision
+	r.mu.RUnlock()
+
 	return simulator.BackendInfo{
 		Name:        "QSim Quantum Simulator",
 		Version:     "v1.0.0",
@@ -127,12 +117,17 @@ func (r *QSimRunner) GetBackendInfo() simulator.BackendInfo {
 			"metrics_collection": true,
 			"configuration":      true,
 			"reset":              true,
+			"gate_fusion":        true,
+			"parallel_kernels":   true,
 		},
 		Metadata: map[string]string{
-			"backend_type":   "statevector_simulator",
-			"language":       "go",
-			"license":        "MIT",
-			"implementation": "from_scratch",
+			"backend_type":    "statevector_simulator",
+			"language":        "go",
+			"license":         "MIT",
+			"implementation":  "from_scratch",
+			"threads":         strconv.Itoa(threads),
+			"parallel_qubits": strconv.Itoa(parallelQubits),
+			"precision":       precision,
 		},
 	}
 }
@@ -163,6 +158,42 @@ func (r *QSimRunner) Configure(options map[string]interface{}) error {
 			} else {
 				return fmt.Errorf("invalid type for 'log_level' option: expected string, got %T", value)
 			}
+		case "fusion":
+			if fusion, ok := value.(bool); ok {
+				r.fusion = fusion
+				r.config[key] = value
+				r.plans.reset()
+			} else {
+				return fmt.Errorf("invalid type for 'fusion' option: expected bool, got %T", value)
+			}
+		case "threads":
+			if threads, ok := value.(int); ok && threads >= 1 {
+				r.threads = threads
+				r.config[key] = value
+			} else {
+				return fmt.Errorf("invalid value for 'threads' option: expected a positive int, got %v (%T)", value, value)
+			}
+		case "parallel_qubits":
+			if qubits, ok := value.(int); ok && qubits >= 1 {
+				r.parallelQubits = qubits
+				r.config[key] = value
+			} else {
+				return fmt.Errorf("invalid value for 'parallel_qubits' option: expected a positive int, got %v (%T)", value, value)
+			}
+		case "share_threads":
+			if share, ok := value.(bool); ok {
+				r.shareThreads = share
+				r.config[key] = value
+			} else {
+				return fmt.Errorf("invalid type for 'share_threads' option: expected bool, got %T", value)
+			}
+		case "precision":
+			if precision, ok := value.(string); ok && (precision == PrecisionDouble || precision == PrecisionSingle) {
+				r.precision = precision
+				r.config[key] = value
+			} else {
+				return fmt.Errorf("invalid value for 'precision' option: expected %q or %q, got %v", PrecisionDouble, PrecisionSingle, value)
+			}
 		case "seed":
 			if _, ok := value.(int64); ok {
 				r.config[key] = value
@@ -193,6 +224,8 @@ func (r *QSimRunner) Reset() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.plans.reset()
+
 	// Reset metrics
 	r.metrics.totalExecutions.Store(0)
 	r.metrics.successfulRuns.Store(0)
@@ -246,8 +279,14 @@ func (r *QSimRunner) ResetMetrics() {
 
 // ValidatingRunner implementation
 func (r *QSimRunner) ValidateCircuit(c circuit.Circuit) error {
-	if c.Qubits() > 20 { // Reasonable limit for demo
-		return fmt.Errorf("circuit has too many qubits: %d (max 20)", c.Qubits())
+	r.mu.RLock()
+	maxQubits := MaxQubits
+	if r.precision == PrecisionSingle {
+		maxQubits++
+	}
+	r.mu.RUnlock()
+	if c.Qubits() > maxQubits {
+		return fmt.Errorf("circuit has too many qubits: %d (max %d)", c.Qubits(), maxQubits)
 	}
 
 	if c.Depth() > 1000 { // Reasonable depth limit
@@ -304,18 +343,12 @@ func (r *QSimRunner) RunBatch(c circuit.Circuit, shots int) ([]string, error) {
 }
 
 // GetResultProbabilities analyzes a circuit and returns theoretical probabilities
-// This is useful for validation against known quantum states
+// This is useful for validation against known quantum states. Keys list the
+// qubits with qubit 0 first, like measurement results.
 func (r *QSimRunner) GetResultProbabilities(c circuit.Circuit) (map[string]float64, error) {
-	// Create a copy of the state without measurements
-	state := NewQuantumState(c.Qubits(), c.Clbits())
-
-	// Apply all non-measurement operations
-	for _, op := range c.Operations() {
-		if op.G.Name() != "MEASURE" {
-			if err := state.ApplyGate(op.G, op.Qubits); err != nil {
-				return nil, fmt.Errorf("failed to apply gate %s: %w", op.G.Name(), err)
-			}
-		}
+	state, err := r.evolve(c)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get probabilities for each computational basis state
@@ -325,14 +358,90 @@ func (r *QSimRunner) GetResultProbabilities(c circuit.Circuit) (map[string]float
 	// Convert to string representation
 	for i, prob := range probs {
 		if prob > 1e-10 { // Only include non-zero probabilities
-			bitString := fmt.Sprintf("%0*b", state.numQubits, i)
-			result[bitString] = prob
+			bits := make([]byte, c.Qubits())
+			for q := range bits {
+				bits[q] = '0' + byte(i>>q&1)
+			}
+			result[string(bits)] = prob
 		}
 	}
 
 	return result, nil
 }
 
+// StateVector returns the final state of the circuit with its measurements
+// left out, so the amplitudes are those just before read-out
+func (r *QSimRunner) StateVector(c circuit.Circuit) ([]complex128, error) {
+	state, err := r.evolve(c)
+	if err != nil {
+		return nil, err
+	}
+	return state.Amplitudes(), nil
+}
+
+// evolve applies all non-measurement operations to a fresh state
+func (r *QSimRunner) evolve(c circuit.Circuit) (stateVector, error) {
+	p, err := r.plan(c)
+	if err != nil {
+		return nil, err
+	}
+	r.running.Add(1)
+	defer r.running.Add(-1)
+	state := r.newState(p)
+	if err := p.run(context.Background(), state, false); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// newState returns a fresh state for the plan in the configured precision,
+// threaded if it is large enough
+func (r *QSimRunner) newState(p *plan) stateVector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	threads := 1
+	if p.qubits >= r.parallelQubits {
+		threads = r.threads
+		if r.shareThreads {
+			threads = kernelThreads(threads, r.shotsInFlight())
+		}
+	}
+	if r.precision == PrecisionSingle {
+		state := NewQuantumState64(p.qubits, p.clbits)
+		state.SetThreads(threads)
+		return state
+	}
+	state := NewQuantumState(p.qubits, p.clbits)
+	state.SetThreads(threads)
+	return state
+}
+
+// shotsInFlight counts the shots running on this runner or, for a pooled
+// runner, on the runners of its pool, each of which runs one at a time
+func (r *QSimRunner) shotsInFlight() int64 {
+	shots := r.running.Load()
+	if r.pool != nil {
+		shots = max(shots, int64(r.pool.InUse()))
+	}
+	return shots
+}
+
+// JoinPool lets kernels share threads with the other runners of the pool
+func (r *QSimRunner) JoinPool(p *simulator.RunnerPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pool = p
+}
+
+// plan returns the execution plan of the circuit, compiling it on first use
+func (r *QSimRunner) plan(c circuit.Circuit) (*plan, error) {
+	r.mu.RLock()
+	fuse := r.fusion
+	r.mu.RUnlock()
+	return r.plans.get(c, fuse)
+}
+
 // Factory function for the plugin system
 func init() {
 	// Register the QSim runner with the plugin system
@@ -6,13 +6,12 @@ package qsim
 import (
 	"fmt"
 	"math"
-	"math/cmplx"
-	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/kegliz/qplay/qc/gate"
+	"github.com/kegliz/qplay/qc/simulator"
 )
 
 // QSimRunner is a quantum circuit simulator built from scratch
@@ -21,6 +20,18 @@ type QSimRunner struct {
 	mu      sync.RWMutex
 	metrics QSimMetrics
 	verbose bool
+	fusion  bool      // fuse gates into 2x2 and 4x4 kernels when compiling
+	plans   planCache // compiled circuits
+
+	threads        int // goroutines per kernel on large states
+	parallelQubits int // smallest state whose kernels use threads
+	// shareThreads splits threads among the shots in flight: those of
+	// this runner or, when it was created by a pool, of the whole pool
+	shareThreads bool
+	running      atomic.Int64          // shots in flight on this runner
+	pool         *simulator.RunnerPool // pool that created the runner
+
+	precision string // PrecisionDouble or PrecisionSingle
 }
 
 // QSimMetrics tracks execution statistics
@@ -39,6 +50,7 @@ type QuantumState struct {
 	amplitudes    []complex128 // State vector amplitudes
 	numClassical  int          // Number of classical bits
 	classicalBits []bool       // Classical bit values
+	threading
 }
 
 // NewQSimRunner creates a new quantum simulator instance
@@ -46,6 +58,13 @@ func NewQSimRunner() *QSimRunner {
 	runner := &QSimRunner{
 		config:  make(map[string]interface{}),
 		verbose: false,
+		fusion:  true,
+
+		threads:        defaultThreads(),
+		parallelQubits: DefaultParallelQubits,
+		shareThreads:   true,
+
+		precision: PrecisionDouble,
 	}
 
 	// Initialize metrics
@@ -76,6 +95,7 @@ func (qs *QuantumState) Clone() *QuantumState {
 		amplitudes:    make([]complex128, len(qs.amplitudes)),
 		numClassical:  qs.numClassical,
 		classicalBits: make([]bool, len(qs.classicalBits)),
+		threading:     qs.threading,
 	}
 
 	copy(newState.amplitudes, qs.amplitudes)
@@ -86,31 +106,37 @@ func (qs *QuantumState) Clone() *QuantumState {
 
 // Normalize ensures the state vector has unit magnitude
 func (qs *QuantumState) Normalize() {
-	var norm float64
-	// Optimized norm calculation
-	for i := 0; i < len(qs.amplitudes); i++ {
-		amp := qs.amplitudes[i]
-		norm += real(amp)*real(amp) + imag(amp)*imag(amp)
-	}
+	norm := qs.sum(len(qs.amplitudes), func(lo, hi int) float64 {
+		var norm float64
+		for i := lo; i < hi; i++ {
+			amp := qs.amplitudes[i]
+			norm += real(amp)*real(amp) + imag(amp)*imag(amp)
+		}
+		return norm
+	})
 
 	if norm > 1e-10 { // Avoid division by zero
 		norm = math.Sqrt(norm)
 		invNorm := complex(1.0/norm, 0)
-		for i := 0; i < len(qs.amplitudes); i++ {
-			qs.amplitudes[i] *= invNorm
-		}
+		qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				qs.amplitudes[i] *= invNorm
+			}
+		})
 	}
 }
 
 // GetProbabilities returns measurement probabilities for each computational basis state
 func (qs *QuantumState) GetProbabilities() []float64 {
-	probs := make([]float64, len(qs.amplitudes))
-	// Optimized probability calculation using manual loop unrolling
-	for i := range qs.amplitudes {
-		amp := qs.amplitudes[i]
-		probs[i] = real(amp)*real(amp) + imag(amp)*imag(amp)
-	}
-	return probs
+	return probabilities(qs.threading, qs.amplitudes)
+}
+
+// Amplitudes returns a copy of the state vector; bit q of an index is the
+// value of qubit q
+func (qs *QuantumState) Amplitudes() []complex128 {
+	amps := make([]complex128, len(qs.amplitudes))
+	copy(amps, qs.amplitudes)
+	return amps
 }
 
 // Measure performs a measurement of specified qubit and collapses the state
@@ -119,58 +145,7 @@ func (qs *QuantumState) Measure(qubit int) bool {
 		return false // Invalid qubit
 	}
 
-	// Calculate probability of measuring |1⟩
-	var probOne float64
-	mask := 1 << qubit
-
-	// Optimized probability calculation
-	for i := mask; i < len(qs.amplitudes); i += 2 << qubit {
-		end := min(i+(1<<qubit), len(qs.amplitudes))
-		for j := i; j < end; j++ {
-			amp := qs.amplitudes[j]
-			probOne += real(amp * cmplx.Conj(amp))
-		}
-	}
-
-	// Perform measurement
-	result := rand.Float64() < probOne
-
-	// Collapse the state - optimized normalization
-	var norm float64
-	if result {
-		// Keep |1⟩ states, zero |0⟩ states
-		for i := range qs.amplitudes {
-			if (i & mask) != 0 {
-				amp := qs.amplitudes[i]
-				norm += real(amp * cmplx.Conj(amp))
-			} else {
-				qs.amplitudes[i] = 0
-			}
-		}
-	} else {
-		// Keep |0⟩ states, zero |1⟩ states
-		for i := range qs.amplitudes {
-			if (i & mask) == 0 {
-				amp := qs.amplitudes[i]
-				norm += real(amp * cmplx.Conj(amp))
-			} else {
-				qs.amplitudes[i] = 0
-			}
-		}
-	}
-
-	// Renormalize
-	if norm > 1e-10 {
-		norm = math.Sqrt(norm)
-		invNorm := complex(1.0/norm, 0)
-		for i := range qs.amplitudes {
-			if (i&mask != 0) == result {
-				qs.amplitudes[i] *= invNorm
-			}
-		}
-	}
-
-	return result
+	return measure(qs.threading, qs.amplitudes, qubit)
 }
 
 // ApplyGate applies a quantum gate to the state
@@ -213,14 +188,16 @@ func (qs *QuantumState) applyHadamard(qubit int) error {
 
 	// Process only half the states (avoid double processing)
 	// Work in-place to avoid memory allocation
-	for i := 0; i < len(qs.amplitudes); i++ {
-		if (i & mask) == 0 { // |0⟩ component
-			j := i | mask // Corresponding |1⟩ state
-			a0, a1 := qs.amplitudes[i], qs.amplitudes[j]
-			qs.amplitudes[i] = invSqrt2 * (a0 + a1)
-			qs.amplitudes[j] = invSqrt2 * (a0 - a1)
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i & mask) == 0 { // |0⟩ component
+				j := i | mask // Corresponding |1⟩ state
+				a0, a1 := qs.amplitudes[i], qs.amplitudes[j]
+				qs.amplitudes[i] = invSqrt2 * (a0 + a1)
+				qs.amplitudes[j] = invSqrt2 * (a0 - a1)
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -233,12 +210,14 @@ func (qs *QuantumState) applyPauliX(qubit int) error {
 	mask := 1 << qubit
 
 	// Optimized X gate: only process pairs once
-	for i := range qs.amplitudes {
-		if (i & mask) == 0 { // Only process |0⟩ states
-			j := i | mask // Corresponding |1⟩ state
-			qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i & mask) == 0 { // Only process |0⟩ states
+				j := i | mask // Corresponding |1⟩ state
+				qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -252,14 +231,16 @@ func (qs *QuantumState) applyPauliY(qubit int) error {
 	i := complex(0, 1) // Imaginary unit
 
 	// Optimized Y gate: only process pairs once
-	for idx := range qs.amplitudes {
-		if (idx & mask) == 0 { // Only process |0⟩ states
-			j := idx | mask // Corresponding |1⟩ state
-			temp := qs.amplitudes[idx]
-			qs.amplitudes[idx] = -i * qs.amplitudes[j]
-			qs.amplitudes[j] = i * temp
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for idx := lo; idx < hi; idx++ {
+			if (idx & mask) == 0 { // Only process |0⟩ states
+				j := idx | mask // Corresponding |1⟩ state
+				temp := qs.amplitudes[idx]
+				qs.amplitudes[idx] = -i * qs.amplitudes[j]
+				qs.amplitudes[j] = i * temp
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -271,11 +252,13 @@ func (qs *QuantumState) applyPauliZ(qubit int) error {
 
 	mask := 1 << qubit
 
-	for i := range qs.amplitudes {
-		if (i & mask) != 0 { // |1⟩ component gets phase flip
-			qs.amplitudes[i] = -qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i & mask) != 0 { // |1⟩ component gets phase flip
+				qs.amplitudes[i] = -qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -288,11 +271,13 @@ func (qs *QuantumState) applyS(qubit int) error {
 	mask := 1 << qubit
 	i := complex(0, 1) // Imaginary unit
 
-	for idx := range qs.amplitudes {
-		if (idx & mask) != 0 { // |1⟩ component gets i phase
-			qs.amplitudes[idx] = i * qs.amplitudes[idx]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for idx := lo; idx < hi; idx++ {
+			if (idx & mask) != 0 { // |1⟩ component gets i phase
+				qs.amplitudes[idx] = i * qs.amplitudes[idx]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -308,12 +293,14 @@ func (qs *QuantumState) applyCNOT(control, target int) error {
 	targetMask := 1 << target
 
 	// Only process states where control is |1⟩ and target is |0⟩
-	for i := 0; i < len(qs.amplitudes); i++ {
-		if (i&controlMask) != 0 && (i&targetMask) == 0 {
-			j := i | targetMask
-			qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&controlMask) != 0 && (i&targetMask) == 0 {
+				j := i | targetMask
+				qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -326,11 +313,13 @@ func (qs *QuantumState) applyCZ(control, target int) error {
 	controlMask := 1 << control
 	targetMask := 1 << target
 
-	for i := range qs.amplitudes {
-		if (i&controlMask) != 0 && (i&targetMask) != 0 { // Both |1⟩
-			qs.amplitudes[i] = -qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&controlMask) != 0 && (i&targetMask) != 0 { // Both |1⟩
+				qs.amplitudes[i] = -qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -344,12 +333,14 @@ func (qs *QuantumState) applySwap(qubit1, qubit2 int) error {
 	mask2 := 1 << qubit2
 
 	// Optimized SWAP: only process states where qubits have different values
-	for i := range qs.amplitudes {
-		if (i&mask1) != 0 && (i&mask2) == 0 { // qubit1=1, qubit2=0
-			j := (i &^ mask1) | mask2 // qubit1=0, qubit2=1
-			qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&mask1) != 0 && (i&mask2) == 0 { // qubit1=1, qubit2=0
+				j := (i &^ mask1) | mask2 // qubit1=0, qubit2=1
+				qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -367,12 +358,14 @@ func (qs *QuantumState) applyToffoli(control1, control2, target int) error {
 	controlMask := mask1 | mask2
 
 	// Only process states where both controls are |1⟩ and target is |0⟩
-	for i := range qs.amplitudes {
-		if (i&controlMask) == controlMask && (i&targetMask) == 0 {
-			j := i | targetMask
-			qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&controlMask) == controlMask && (i&targetMask) == 0 {
+				j := i | targetMask
+				qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
+			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -386,23 +379,16 @@ func (qs *QuantumState) applyFredkin(control, target1, target2 int) error {
 	mask1 := 1 << target1
 	mask2 := 1 << target2
 
-	for i := range qs.amplitudes {
-		if (i & controlMask) != 0 { // Control is |1⟩
-			bit1 := (i & mask1) != 0
-			bit2 := (i & mask2) != 0
-
-			if bit1 != bit2 { // Only swap if bits are different
-				j := i
-				if bit1 { // target1 is 1, target2 is 0
-					j = (i &^ mask1) | mask2 // Set target1 to 0, target2 to 1
-				} else { // target1 is 0, target2 is 1
-					j = (i &^ mask2) | mask1 // Set target1 to 1, target2 to 0
-				}
-
+	// Only process states where control is |1⟩, target1 is |1⟩ and
+	// target2 is |0⟩; each swapped pair is visited once
+	qs.parallel(len(qs.amplitudes), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			if (i&controlMask) != 0 && (i&mask1) != 0 && (i&mask2) == 0 {
+				j := (i &^ mask1) | mask2 // Set target1 to 0, target2 to 1
 				qs.amplitudes[i], qs.amplitudes[j] = qs.amplitudes[j], qs.amplitudes[i]
 			}
 		}
-	}
+	})
 
 	return nil
 }
@@ -0,0 +1,118 @@
+// Package qsim - Single-precision state vectors
+package qsim
+
+import (
+	"fmt"
+
+	"github.com/kegliz/qplay/qc/gate"
+)
+
+// Precision modes of the runner
+const (
+	PrecisionDouble = "double" // complex128 amplitudes, 16 bytes each
+	PrecisionSingle = "single" // complex64 amplitudes, 8 bytes each
+)
+
+type (
+	// stateVector is what a plan runs on: a double- or single-precision
+	// state.
+	stateVector interface {
+		ApplyGate(g gate.Gate, qubits []int) error
+		Measure(qubit int) bool
+		GetProbabilities() []float64
+		Amplitudes() []complex128
+		applyMatrix1(q int, m *[4]complex128)
+		applyMatrix2(q0, q1 int, m *[16]complex128)
+		clbits() []bool
+	}
+
+	// QuantumState64 is a statevector stored in single precision. It takes
+	// half the memory of a QuantumState, so it reaches one more qubit in
+	// the same space, at roughly 1e-7 relative error per amplitude.
+	QuantumState64 struct {
+		numQubits     int
+		amplitudes    []complex64
+		classicalBits []bool
+		threading
+	}
+)
+
+// NewQuantumState64 creates a single-precision state with n qubits in the
+// |0...0⟩ state
+func NewQuantumState64(numQubits, numClassical int) *QuantumState64 {
+	amplitudes := make([]complex64, 1<<numQubits)
+	amplitudes[0] = 1
+	return &QuantumState64{
+		numQubits:     numQubits,
+		amplitudes:    amplitudes,
+		classicalBits: make([]bool, numClassical),
+	}
+}
+
+// Amplitudes returns the state vector widened to complex128; bit q of an
+// index is the value of qubit q
+func (qs *QuantumState64) Amplitudes() []complex128 {
+	amps := make([]complex128, len(qs.amplitudes))
+	for i, a := range qs.amplitudes {
+		amps[i] = complex128(a)
+	}
+	return amps
+}
+
+// GetProbabilities returns measurement probabilities for each computational basis state
+func (qs *QuantumState64) GetProbabilities() []float64 {
+	return probabilities(qs.threading, qs.amplitudes)
+}
+
+// Measure performs a measurement of specified qubit and collapses the state
+func (qs *QuantumState64) Measure(qubit int) bool {
+	if qubit >= qs.numQubits {
+		return false // Invalid qubit
+	}
+	return measure(qs.threading, qs.amplitudes, qubit)
+}
+
+// ApplyGate applies a quantum gate to the state
+func (qs *QuantumState64) ApplyGate(g gate.Gate, qubits []int) error {
+	for _, q := range qubits {
+		if q < 0 || q >= qs.numQubits {
+			return fmt.Errorf("invalid qubit %d for %d-qubit system", q, qs.numQubits)
+		}
+	}
+	name := g.Name()
+	switch len(qubits) {
+	case 1:
+		if m, ok := matrix1(name); ok {
+			qs.applyMatrix1(qubits[0], &m)
+			return nil
+		}
+	case 2:
+		if m, ok := matrix2(name); ok {
+			qs.applyMatrix2(qubits[0], qubits[1], &m)
+			return nil
+		}
+	case 3:
+		a, b, c := 1<<qubits[0], 1<<qubits[1], 1<<qubits[2]
+		switch name {
+		case "TOFFOLI": // controls a, b; flip target c where it is |0⟩
+			swapWhere(qs.threading, qs.amplitudes, a|b|c, a|b, c)
+			return nil
+		case "FREDKIN": // control a; swap b=1,c=0 with b=0,c=1
+			swapWhere(qs.threading, qs.amplitudes, a|b|c, a|b, b|c)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported gate: %s", name)
+}
+
+func (qs *QuantumState64) applyMatrix1(q int, m *[4]complex128) {
+	applyMatrix1(qs.threading, qs.amplitudes, q, m)
+}
+
+func (qs *QuantumState64) applyMatrix2(q0, q1 int, m *[16]complex128) {
+	applyMatrix2(qs.threading, qs.amplitudes, q0, q1, m)
+}
+
+func (qs *QuantumState64) clbits() []bool { return qs.classicalBits }
+
+func (qs *QuantumState) clbits() []bool { return qs.classicalBits }
@@ -72,6 +72,18 @@ func (r *RunnerRegistry) Create(name string) (OneShotRunner, error) {
 	return runner, nil
 }
 
+// Factory returns the factory registered under the given name.
+func (r *RunnerRegistry) Factory(name string) (RunnerFactory, error) {
+	r.mu.RLock()
+	factory, exists := r.factories[name]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown runner: %q", name)
+	}
+	return factory, nil
+}
+
 // ListRunners returns a list of all registered runner names.
 func (r *RunnerRegistry) ListRunners() []string {
 	r.mu.RLock()
@@ -114,6 +126,11 @@ func CreateRunner(name string) (OneShotRunner, error) {
 	return defaultRegistry.Create(name)
 }
 
+// GetRunnerFactory returns a runner factory from the default registry.
+func GetRunnerFactory(name string) (RunnerFactory, error) {
+	return defaultRegistry.Factory(name)
+}
+
 // ListRunners returns all registered runner names from the default registry.
 func ListRunners() []string {
 	return defaultRegistry.ListRunners()
@@ -0,0 +1,618 @@
+// Package remote fans the shots of a circuit out to qplay worker processes
+// (see cmd/qplay-worker) and merges their histograms. The Runner is
+// registered as the "remote" backend, so a Simulator can use a set of
+// workers like any local runner.
+//
+// Workers speak a small JSON protocol over HTTP: a POST to ExecutePath with
+// a spec.Circuit and a shot count answers with the measurement histogram.
+// A qplay web server speaks it too, so a single server URL can stand in for
+// the workers; set "max_shots" to its shot limit.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kegliz/qplay/internal/logger"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/simulator"
+	"github.com/kegliz/qplay/qc/spec"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// ExecutePath is the endpoint workers serve.
+	ExecutePath = "/api/execute"
+	// WorkersEnv holds a comma-separated list of worker URLs that runners
+	// created from the registry start with.
+	WorkersEnv = "QPLAY_REMOTE_WORKERS"
+	// DefaultBatch is the most shots RunOnce fetches at a time.
+	DefaultBatch = 1024
+	// DefaultMaxShots caps the shots of one request; it matches the default
+	// shot limit of a qplay server, which would otherwise run fewer.
+	DefaultMaxShots = 10000
+	// DefaultRetries is the number of times a failed partition is retried.
+	DefaultRetries = 2
+)
+
+// firstBatch is the size of the first refill of RunOnce for a circuit;
+// later refills double with the shots served, up to the batch option, so
+// a run fetches at most about twice the shots it uses.
+const firstBatch = 16
+
+// supportedGates are the gates a spec can carry to the workers.
+var supportedGates = []string{
+	"H", "X", "Y", "Z", "S", "CNOT", "CZ", "SWAP", "TOFFOLI", "FREDKIN", "MEASURE",
+}
+
+// retryBackoff is the pause before the first retry of a partition; it grows
+// linearly with every further attempt.
+var retryBackoff = 100 * time.Millisecond
+
+type (
+	executeRequest struct {
+		Circuit spec.Circuit `json:"circuit"`
+		Backend string       `json:"backend,omitempty"`
+		Shots   int          `json:"shots"`
+		// NoCache keeps a qplay server from answering every partition with
+		// the same cached histogram.
+		NoCache bool `json:"no_cache"`
+	}
+
+	executeResponse struct {
+		Measurements map[string]int `json:"measurements"`
+		Backend      string         `json:"backend"`
+		Shots        int            `json:"shots"`
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	// refill is a fetch of shots for RunOnce in flight; done is closed
+	// once err is set.
+	refill struct {
+		key  string
+		done chan struct{}
+		err  error
+	}
+
+	// statusError is a non-200 answer of a worker.
+	statusError struct {
+		code int
+		msg  string
+	}
+
+	// Runner splits the shots of a circuit across its workers, at least
+	// one partition per worker and at most maxShots shots each, and merges
+	// the histograms they return. A failed partition is retried on the
+	// next worker in turn; requests the worker rejects (4xx other than 429)
+	// are not retried.
+	Runner struct {
+		log    logger.Logger
+		client *http.Client
+
+		mu       sync.RWMutex
+		workers  []string
+		backend  string // backend the workers should run ("" = their own)
+		token    string // sent as a bearer token
+		batch    int
+		maxShots int
+		retries  int
+		config   map[string]any
+
+		// RunOnce serves shots of the last circuit from buf, with at most
+		// one refill in flight; bufGen changes when Configure invalidates
+		// the shots.
+		bufMu     sync.Mutex
+		bufKey    string
+		buf       []string
+		bufServed int // shots of bufKey served so far
+		bufGen    uint64
+		refill    *refill
+
+		next    atomic.Uint64 // worker the next run starts with
+		metrics metrics
+	}
+
+	metrics struct {
+		mu sync.Mutex
+		m  simulator.ExecutionMetrics
+	}
+)
+
+// NewRunner creates a runner for the given worker URLs.
+func NewRunner(workers ...string) *Runner {
+	return &Runner{
+		log:      *logger.NewLogger(logger.LoggerOptions{Debug: false}),
+		client:   &http.Client{},
+		workers:  workers,
+		batch:    DefaultBatch,
+		maxShots: DefaultMaxShots,
+		retries:  DefaultRetries,
+		config:   make(map[string]any),
+	}
+}
+
+func (e *statusError) Error() string { return fmt.Sprintf("%d %s", e.code, e.msg) }
+
+// retryable tells whether another attempt may succeed.
+func (e *statusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusTooManyRequests || e.code == http.StatusRequestTimeout
+}
+
+// RunOnce implements simulator.OneShotRunner. Fetching shots one by one
+// would cost a round trip each, so RunOnce fetches a batch and serves the
+// following calls for the same circuit from it; the shots are independent,
+// so this does not change the statistics.
+func (r *Runner) RunOnce(c circuit.Circuit) (string, error) {
+	return r.RunOnceWithContext(context.Background(), c)
+}
+
+// ContextualRunner implementation; ctx bounds the requests of a refill.
+// Callers that find the buffer empty while a refill is in flight wait for
+// it instead of fetching shots of their own.
+func (r *Runner) RunOnceWithContext(ctx context.Context, c circuit.Circuit) (string, error) {
+	key := circuit.Hash(c)
+	for {
+		r.mu.RLock()
+		batch := r.batch
+		r.mu.RUnlock()
+
+		r.bufMu.Lock()
+		if r.bufKey == key && len(r.buf) > 0 {
+			shot := r.buf[len(r.buf)-1]
+			r.buf = r.buf[:len(r.buf)-1]
+			r.bufServed++
+			r.bufMu.Unlock()
+			return shot, nil
+		}
+		if f := r.refill; f != nil {
+			r.bufMu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+			// a refill given up by its own caller is retried by the next
+			if f.key == key && f.err != nil && !errors.Is(f.err, context.Canceled) && !errors.Is(f.err, context.DeadlineExceeded) {
+				return "", f.err
+			}
+			continue
+		}
+
+		if r.bufKey != key {
+			r.bufKey, r.buf, r.bufServed = key, nil, 0
+		}
+		f := &refill{key: key, done: make(chan struct{})}
+		r.refill = f
+		n := min(batch, max(firstBatch, r.bufServed))
+		gen := r.bufGen
+		r.bufMu.Unlock()
+
+		shots, err := r.RunBatchWithContext(ctx, c, n)
+
+		r.bufMu.Lock()
+		r.refill = nil
+		f.err = err
+		close(f.done)
+		if err != nil {
+			r.bufMu.Unlock()
+			return "", err
+		}
+		if r.bufGen == gen && r.bufKey == key {
+			r.buf = append(r.buf, shots[1:]...)
+			r.bufServed++
+		}
+		r.bufMu.Unlock()
+		return shots[0], nil
+	}
+}
+
+// resetBuffer drops the buffered shots, including those of a refill in
+// flight.
+func (r *Runner) resetBuffer() {
+	r.bufMu.Lock()
+	defer r.bufMu.Unlock()
+	r.bufKey, r.buf, r.bufServed = "", nil, 0
+	r.bufGen++
+}
+
+// RunBatch implements simulator.BatchRunner; the shots come in random order.
+func (r *Runner) RunBatch(c circuit.Circuit, shots int) ([]string, error) {
+	return r.RunBatchWithContext(context.Background(), c, shots)
+}
+
+// RunBatchWithContext is RunBatch honouring ctx.
+func (r *Runner) RunBatchWithContext(ctx context.Context, c circuit.Circuit, shots int) ([]string, error) {
+	hist, err := r.Run(ctx, c, shots)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, shots)
+	for key, n := range hist {
+		for range n {
+			out = append(out, key)
+		}
+	}
+	rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out, nil
+}
+
+// Run executes the circuit for the given number of shots on the workers and
+// returns the merged histogram.
+func (r *Runner) Run(ctx context.Context, c circuit.Circuit, shots int) (map[string]int, error) {
+	if shots <= 0 {
+		return nil, fmt.Errorf("shots must be positive, got %d", shots)
+	}
+	if err := r.ValidateCircuit(c); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	workers := append([]string(nil), r.workers...)
+	backend, maxShots := r.backend, r.maxShots
+	r.mu.RUnlock()
+	if len(workers) == 0 {
+		return nil, fmt.Errorf("remote: no workers configured (set %s or the \"workers\" option)", WorkersEnv)
+	}
+
+	start := time.Now()
+	req := executeRequest{Circuit: spec.FromCircuit(c), Backend: backend, NoCache: true}
+	parts := max(min(shots, len(workers)), (shots+maxShots-1)/maxShots)
+	first := int(r.next.Add(uint64(parts)) - uint64(parts))
+
+	hist := make(map[string]int)
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	// as many requests in flight as there are workers
+	slots := make(chan struct{}, len(workers))
+	for p := range parts {
+		n := shots / parts
+		if p < shots%parts {
+			n++
+		}
+		wg.Add(1)
+		go func(p, n int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			h, err := r.runPartition(ctx, workers, first+p, req, n)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("partition %d (%d shots): %w", p, n, err))
+				return
+			}
+			for k, v := range h {
+				hist[k] += v
+			}
+		}(p, n)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	r.metrics.record(start, shots, err)
+	if err != nil {
+		return nil, err
+	}
+	return hist, nil
+}
+
+// runPartition runs n shots on worker start and, when that fails, retries
+// on the following workers with a growing pause.
+func (r *Runner) runPartition(ctx context.Context, workers []string, start int, req executeRequest, n int) (map[string]int, error) {
+	r.mu.RLock()
+	retries := r.retries
+	r.mu.RUnlock()
+
+	req.Shots = n
+	var errs []error
+	for a := range retries + 1 {
+		if a > 0 {
+			select {
+			case <-time.After(time.Duration(a) * retryBackoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		url := workers[(start+a)%len(workers)]
+		hist, err := r.post(ctx, url, &req)
+		if err == nil {
+			return hist, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		r.log.Warn().Err(err).Str("worker", url).Int("shots", n).Int("attempt", a+1).Msg("remote: partition failed")
+		errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		var se *statusError
+		if errors.As(err, &se) && !se.retryable() {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// post sends one partition to a worker.
+func (r *Runner) post(ctx context.Context, url string, req *executeRequest) (map[string]int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(url, "/")+ExecutePath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	hr.Header.Set("Content-Type", "application/json")
+	r.mu.RLock()
+	if r.token != "" {
+		hr.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	client := r.client
+	r.mu.RUnlock()
+	resp, err := client.Do(hr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return nil, &statusError{code: resp.StatusCode, msg: e.Error}
+	}
+	var out executeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	total := 0
+	for _, v := range out.Measurements {
+		total += v
+	}
+	if total != req.Shots {
+		return nil, fmt.Errorf("worker ran %d shots, want %d", total, req.Shots)
+	}
+	r.log.Debug().Str("worker", url).Int("shots", total).Msg("remote: partition done")
+	return out.Measurements, nil
+}
+
+// ValidatingRunner implementation. Circuits travel as specs, and building
+// a spec measures every qubit when it has no MEASURE gates, so a circuit
+// without measurements would come back with full bitstrings where a local
+// runner returns "0"; such circuits are rejected instead.
+func (r *Runner) ValidateCircuit(c circuit.Circuit) error {
+	measured := false
+	for _, op := range c.Operations() {
+		name := op.G.Name()
+		if !slices.Contains(supportedGates, name) {
+			return fmt.Errorf("unsupported gate: %s", name)
+		}
+		measured = measured || name == "MEASURE"
+	}
+	if !measured {
+		return errors.New("remote: circuit has no measurements; workers would measure every qubit")
+	}
+	return nil
+}
+
+func (r *Runner) GetSupportedGates() []string {
+	return slices.Clone(supportedGates)
+}
+
+// Workers returns the configured worker URLs.
+func (r *Runner) Workers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.workers...)
+}
+
+// BackendProvider implementation
+func (r *Runner) GetBackendInfo() simulator.BackendInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return simulator.BackendInfo{
+		Name:        "Remote Workers",
+		Version:     "v1.0.0",
+		Description: "Distributes shots across qplay worker processes and merges their histograms",
+		Vendor:      "qplay",
+		Capabilities: map[string]bool{
+			"context_support":    true,
+			"batch_execution":    true,
+			"circuit_validation": true,
+			"configuration":      true,
+			"metrics_collection": true,
+		},
+		Metadata: map[string]string{
+			"backend_type": "distributed",
+			"workers":      strings.Join(r.workers, ","),
+			"backend":      r.backend,
+		},
+	}
+}
+
+// ConfigurableRunner implementation. Options:
+//
+//	workers    []string or comma-separated string of base URLs
+//	url        a single base URL, e.g. of a qplay server
+//	token      bearer token sent with every request
+//	timeout    per-request timeout, a time.Duration or a string like "30s"
+//	retries    attempts after the first one for a failed partition
+//	max_shots  most shots per request
+//	backend    the backend workers run ("" = their default)
+//	batch      shots RunOnce fetches at a time
+//	verbose    log every partition
+func (r *Runner) Configure(options map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// shots fetched from other workers or backends are stale
+	stale := false
+	defer func() {
+		if stale {
+			r.resetBuffer()
+		}
+	}()
+
+	for key, value := range options {
+		switch key {
+		case "url", "workers", "backend", "max_shots":
+			stale = true
+		}
+		switch key {
+		case "url":
+			v, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("invalid type for 'url' option: expected string, got %T", value)
+			}
+			r.workers = splitList(v)
+		case "token":
+			v, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("invalid type for 'token' option: expected string, got %T", value)
+			}
+			r.token = v
+			value = "***" // kept out of GetConfiguration
+		case "timeout":
+			var d time.Duration
+			switch v := value.(type) {
+			case time.Duration:
+				d = v
+			case string:
+				var err error
+				if d, err = time.ParseDuration(v); err != nil {
+					return fmt.Errorf("invalid 'timeout' option: %w", err)
+				}
+			default:
+				return fmt.Errorf("invalid type for 'timeout' option: expected time.Duration or string, got %T", value)
+			}
+			if d < 0 {
+				return fmt.Errorf("invalid 'timeout' option: must not be negative, got %v", d)
+			}
+			r.client = &http.Client{Timeout: d}
+		case "retries":
+			v, ok := value.(int)
+			if !ok || v < 0 {
+				return fmt.Errorf("invalid 'retries' option: expected a non-negative int, got %v", value)
+			}
+			r.retries = v
+		case "max_shots":
+			v, ok := value.(int)
+			if !ok || v <= 0 {
+				return fmt.Errorf("invalid 'max_shots' option: expected a positive int, got %v", value)
+			}
+			r.maxShots = v
+		case "workers":
+			switch v := value.(type) {
+			case []string:
+				r.workers = append([]string(nil), v...)
+			case string:
+				r.workers = splitList(v)
+			default:
+				return fmt.Errorf("invalid type for 'workers' option: expected []string or string, got %T", value)
+			}
+		case "backend":
+			v, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("invalid type for 'backend' option: expected string, got %T", value)
+			}
+			r.backend = v
+		case "batch":
+			v, ok := value.(int)
+			if !ok || v <= 0 {
+				return fmt.Errorf("invalid 'batch' option: expected a positive int, got %v", value)
+			}
+			r.batch = v
+		case "verbose":
+			v, ok := value.(bool)
+			if !ok {
+				return fmt.Errorf("invalid type for 'verbose' option: expected bool, got %T", value)
+			}
+			r.setVerbose(v)
+		}
+		r.config[key] = value
+	}
+	return nil
+}
+
+func (r *Runner) GetConfiguration() map[string]interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	config := make(map[string]any, len(r.config))
+	maps.Copy(config, r.config)
+	return config
+}
+
+func (r *Runner) SetVerbose(verbose bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setVerbose(verbose)
+}
+
+func (r *Runner) setVerbose(verbose bool) {
+	if verbose {
+		r.log.Logger = r.log.Logger.Level(zerolog.DebugLevel)
+	} else {
+		r.log.Logger = r.log.Logger.Level(zerolog.InfoLevel)
+	}
+}
+
+// MetricsCollector implementation; every shot counts as one execution.
+func (r *Runner) GetMetrics() simulator.ExecutionMetrics {
+	r.metrics.mu.Lock()
+	defer r.metrics.mu.Unlock()
+	return r.metrics.m
+}
+
+func (r *Runner) ResetMetrics() {
+	r.metrics.mu.Lock()
+	defer r.metrics.mu.Unlock()
+	r.metrics.m = simulator.ExecutionMetrics{}
+}
+
+func (m *metrics) record(start time.Time, shots int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m.TotalExecutions += int64(shots)
+	if err != nil {
+		m.m.FailedRuns += int64(shots)
+		m.m.LastError = err.Error()
+	} else {
+		m.m.SuccessfulRuns += int64(shots)
+		m.m.LastError = ""
+	}
+	m.m.TotalTime += time.Since(start)
+	m.m.AverageTime = m.m.TotalTime / time.Duration(m.m.TotalExecutions)
+	m.m.LastRunTime = start
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Factory function for the plugin system
+func init() {
+	simulator.MustRegisterRunner("remote", func() simulator.OneShotRunner {
+		return NewRunner(splitList(os.Getenv(WorkersEnv))...)
+	})
+}
@@ -0,0 +1,352 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/simulator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/kegliz/qplay/qc/simulator/qsim"
+)
+
+// testWorker serves a qsim worker and counts the shots it is asked for.
+type testWorker struct {
+	*httptest.Server
+	requests atomic.Int32
+	shots    atomic.Int32
+}
+
+func newTestWorker(t *testing.T, fail bool) *testWorker {
+	w, err := NewWorker(WorkerOptions{Backend: "qsim", Workers: 2})
+	require.NoError(t, err)
+	tw := &testWorker{}
+	tw.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		tw.requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		var req executeRequest
+		if json.Unmarshal(body, &req) == nil {
+			tw.shots.Add(int32(req.Shots))
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if fail {
+			writeError(rw, http.StatusInternalServerError, "worker down")
+			return
+		}
+		w.ServeHTTP(rw, r)
+	}))
+	t.Cleanup(tw.Close)
+	return tw
+}
+
+func xCircuit(t *testing.T) circuit.Circuit {
+	b := builder.New(builder.Q(2), builder.C(2))
+	b.X(1).Measure(0, 0).Measure(1, 1)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+	return c
+}
+
+func TestRunner_Run(t *testing.T) {
+	c := xCircuit(t)
+
+	t.Run("Split", func(t *testing.T) {
+		ws := []*testWorker{newTestWorker(t, false), newTestWorker(t, false), newTestWorker(t, false)}
+		r := NewRunner(ws[0].URL, ws[1].URL, ws[2].URL)
+
+		hist, err := r.Run(context.Background(), c, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"01": 1000}, hist)
+		for i, w := range ws {
+			assert.Equal(t, int32(1), w.requests.Load(), "worker %d gets one partition", i)
+		}
+		assert.Equal(t, int64(1000), r.GetMetrics().SuccessfulRuns)
+	})
+
+	t.Run("NoMeasurement", func(t *testing.T) {
+		w := newTestWorker(t, false)
+		r := NewRunner(w.URL)
+		b := builder.New(builder.Q(2), builder.C(2))
+		b.X(0)
+		unmeasured, err := b.BuildCircuit()
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, r.ValidateCircuit(unmeasured), "no measurements")
+		_, err = r.Run(context.Background(), unmeasured, 10)
+		assert.Error(t, err, "workers would measure every qubit")
+		assert.Equal(t, int32(0), w.requests.Load())
+		assert.NoError(t, r.ValidateCircuit(c))
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		good, bad := newTestWorker(t, false), newTestWorker(t, true)
+		r := NewRunner(bad.URL, good.URL)
+
+		hist, err := r.Run(context.Background(), c, 100)
+		require.NoError(t, err)
+		assert.Equal(t, 100, hist["01"])
+		assert.Equal(t, int32(2), good.requests.Load(), "the failed partition moves to the good worker")
+	})
+
+	t.Run("AllFail", func(t *testing.T) {
+		bad := newTestWorker(t, true)
+		r := NewRunner(bad.URL)
+		_, err := r.Run(context.Background(), c, 10)
+		assert.ErrorContains(t, err, "500 worker down")
+		assert.Equal(t, int64(10), r.GetMetrics().FailedRuns)
+	})
+
+	t.Run("NoWorkers", func(t *testing.T) {
+		_, err := NewRunner().Run(context.Background(), c, 10)
+		assert.ErrorContains(t, err, WorkersEnv)
+	})
+
+	t.Run("ShortCount", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			writeJSON(rw, http.StatusOK, executeResponse{Measurements: map[string]int{"01": 3}})
+		}))
+		defer srv.Close()
+		_, err := NewRunner(srv.URL).Run(context.Background(), c, 10)
+		assert.ErrorContains(t, err, "worker ran 3 shots, want 10")
+	})
+}
+
+func TestRunner_Simulator(t *testing.T) {
+	c := xCircuit(t)
+	w1, w2 := newTestWorker(t, false), newTestWorker(t, false)
+	t.Setenv(WorkersEnv, w1.URL+", "+w2.URL)
+
+	sim, err := simulator.NewSimulatorWithRunner("remote", simulator.SimulatorOptions{Shots: 500, Workers: 1})
+	require.NoError(t, err)
+	hist, err := sim.Run(c)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"01": 500}, hist)
+	fetched := w1.shots.Load() + w2.shots.Load()
+	assert.LessOrEqual(t, fetched, int32(2*500+firstBatch), "refills grow with the shots served")
+	assert.Less(t, w1.requests.Load()+w2.requests.Load(), int32(20), "RunOnce fetches shots in batches")
+
+	r := NewRunner(w1.URL)
+	require.NoError(t, r.Configure(map[string]interface{}{"workers": w2.URL, "batch": 10, "backend": "qsim"}))
+	assert.Equal(t, []string{w2.URL}, r.Workers())
+	shots, err := r.RunBatch(c, 25)
+	require.NoError(t, err)
+	assert.Len(t, shots, 25)
+	assert.Error(t, r.Configure(map[string]interface{}{"batch": 0}))
+}
+
+// TestRunner_SharedRefill runs one runner from many simulator workers: they
+// share its refills instead of each fetching a batch of its own.
+func TestRunner_SharedRefill(t *testing.T) {
+	c := xCircuit(t)
+	for _, pooled := range []bool{false, true} {
+		w := newTestWorker(t, false)
+		var sim *simulator.Simulator
+		if pooled {
+			t.Setenv(WorkersEnv, w.URL)
+			var err error
+			sim, err = simulator.NewSimulatorWithRunner("remote", simulator.SimulatorOptions{Shots: 1000, Workers: 8})
+			require.NoError(t, err)
+		} else {
+			sim = simulator.NewSimulator(simulator.SimulatorOptions{Shots: 1000, Workers: 8, Runner: NewRunner(w.URL)})
+		}
+		hist, err := sim.Run(c)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"01": 1000}, hist)
+		assert.LessOrEqual(t, w.shots.Load(), int32(2*1000+8*firstBatch), "pooled=%v", pooled)
+	}
+}
+
+func TestRunner_ConfigureDropsBuffer(t *testing.T) {
+	c := xCircuit(t)
+	w1, w2 := newTestWorker(t, false), newTestWorker(t, false)
+	r := NewRunner(w1.URL)
+	_, err := r.RunOnce(c)
+	require.NoError(t, err)
+	require.NoError(t, r.Configure(map[string]interface{}{"workers": w2.URL}))
+	_, err = r.RunOnce(c)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), w2.requests.Load(), "shots of the old workers are not served")
+
+	requests := w2.requests.Load()
+	require.NoError(t, r.Configure(map[string]interface{}{"verbose": false}))
+	_, err = r.RunOnce(c)
+	require.NoError(t, err)
+	assert.Equal(t, requests, w2.requests.Load(), "other options keep the buffer")
+}
+
+func TestWorker(t *testing.T) {
+	w, err := NewWorker(WorkerOptions{Backend: "qsim", MaxQubits: 2, MaxShots: 100})
+	require.NoError(t, err)
+	_, err = NewWorker(WorkerOptions{Backend: "nope"})
+	assert.Error(t, err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		w.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ExecutePath, strings.NewReader(body)))
+		return rec
+	}
+	rec := post(`{"circuit":{"qubits":1,"gates":[{"type":"X","qubits":[0]}]},"shots":20}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"measurements":{"1":20},"backend":"qsim","shots":20}`, rec.Body.String())
+
+	for body, msg := range map[string]string{
+		`{"circuit":{"qubits":1,"gates":[]},"shots":0}`:                  "shots must be positive",
+		`{"circuit":{"qubits":1,"gates":[]},"shots":101}`:                "at most 100",
+		`{"circuit":{"qubits":3,"gates":[]},"shots":1}`:                  "exceeds the limit of 2",
+		`{"circuit":{"qubits":1,"gates":[]},"shots":1,"backend":"itsu"}`: "this worker runs",
+		`{"circuit":{"qubits":1,"gates":[{"type":"nope"}]},"shots":1}`:   "Invalid circuit",
+		`not json`: "Invalid request format",
+	} {
+		rec := post(body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		assert.Contains(t, rec.Body.String(), msg, body)
+	}
+}
+
+// standIn mimics the /api/execute endpoint of a qplay server: it checks the
+// bearer token, caps the shots and answers with a fixed outcome.
+type standIn struct {
+	*httptest.Server
+	requests atomic.Int32
+	failures atomic.Int32 // answer this many requests with code first
+	code     int
+	delay    time.Duration
+}
+
+func newStandIn(t *testing.T, maxShots int) *standIn {
+	s := &standIn{code: http.StatusServiceUnavailable}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if r.URL.Path != ExecutePath || r.Method != http.MethodPost {
+			writeError(rw, http.StatusNotFound, "not found")
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			writeError(rw, http.StatusUnauthorized, "Missing or invalid API key")
+			return
+		}
+		if s.failures.Add(-1) >= 0 {
+			writeError(rw, s.code, "try again")
+			return
+		}
+		select {
+		case <-time.After(s.delay):
+		case <-r.Context().Done():
+			return
+		}
+		var req executeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.NoCache {
+			writeError(rw, http.StatusBadRequest, "Invalid request format")
+			return
+		}
+		if req.Shots > maxShots {
+			writeError(rw, http.StatusBadRequest, "too many shots")
+			return
+		}
+		writeJSON(rw, http.StatusOK, executeResponse{Measurements: map[string]int{"1": req.Shots}, Backend: req.Backend, Shots: req.Shots})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRunner_Server(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+	c := xCircuit(t)
+	configure := func(t *testing.T, s *standIn, opts map[string]interface{}) *Runner {
+		r := NewRunner()
+		require.NoError(t, r.Configure(map[string]interface{}{"url": s.URL, "token": "secret", "max_shots": 100}))
+		require.NoError(t, r.Configure(opts))
+		return r
+	}
+
+	t.Run("Forward", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		r := configure(t, s, nil)
+		hist, err := r.Run(context.Background(), c, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"1": 1000}, hist)
+		assert.Equal(t, int32(10), s.requests.Load(), "partitions respect max_shots")
+		assert.Equal(t, "***", r.GetConfiguration()["token"], "the token is not exposed")
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		r := configure(t, s, map[string]interface{}{"token": "wrong"})
+		_, err := r.Run(context.Background(), c, 10)
+		assert.ErrorContains(t, err, "401 Missing or invalid API key")
+		assert.Equal(t, int32(1), s.requests.Load(), "client errors are not retried")
+	})
+
+	t.Run("Retries", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		s.failures.Store(2)
+		r := configure(t, s, map[string]interface{}{"retries": 2})
+		hist, err := r.Run(context.Background(), c, 50)
+		require.NoError(t, err)
+		assert.Equal(t, 50, hist["1"])
+		assert.Equal(t, int32(3), s.requests.Load())
+
+		s.failures.Store(2)
+		require.NoError(t, r.Configure(map[string]interface{}{"retries": 1}))
+		_, err = r.Run(context.Background(), c, 50)
+		assert.ErrorContains(t, err, "503 try again")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		s.delay = time.Second
+		r := configure(t, s, map[string]interface{}{"timeout": "20ms", "retries": 0})
+		start := time.Now()
+		_, err := r.Run(context.Background(), c, 10)
+		assert.ErrorContains(t, err, "Timeout")
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		s.delay = time.Second
+		r := configure(t, s, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := r.RunOnceWithContext(ctx, c)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Simulator", func(t *testing.T) {
+		s := newStandIn(t, 100)
+		r := configure(t, s, map[string]interface{}{"batch": 100})
+		sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: 300, Workers: 1, Runner: r})
+		hist, err := sim.RunContext(context.Background(), c)
+		require.NoError(t, err)
+		assert.Equal(t, 300, hist["1"])
+		assert.Equal(t, int32(6), s.requests.Load(), "refills of 16, 16, 32, 64, then the batch of 100")
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		r := NewRunner()
+		for _, opts := range []map[string]interface{}{
+			{"url": 1},
+			{"token": true},
+			{"timeout": "soon"},
+			{"timeout": -time.Second},
+			{"retries": -1},
+			{"max_shots": 0},
+		} {
+			assert.Error(t, r.Configure(opts), "%v", opts)
+		}
+		require.NoError(t, r.Configure(map[string]interface{}{"timeout": 5 * time.Second, "url": "http://a, http://b"}))
+		assert.Equal(t, []string{"http://a", "http://b"}, r.Workers())
+	})
+}
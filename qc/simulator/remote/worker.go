@@ -0,0 +1,108 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/kegliz/qplay/qc/simulator"
+)
+
+type (
+	// WorkerOptions configure a Worker.
+	WorkerOptions struct {
+		// Backend names the registered runner the worker simulates with.
+		Backend string
+		// Workers is the number of goroutines per request (0 => NumCPU).
+		Workers int
+		// MaxQubits and MaxShots bound a single request (0 => no limit).
+		MaxQubits int
+		MaxShots  int
+	}
+
+	// Worker serves the execute protocol of the remote runner, running the
+	// circuits it receives on a local backend. Its runners are pooled across
+	// requests.
+	Worker struct {
+		opts WorkerOptions
+		pool *simulator.RunnerPool
+		mux  *http.ServeMux
+	}
+)
+
+// NewWorker creates a worker for a registered backend.
+func NewWorker(opts WorkerOptions) (*Worker, error) {
+	factory, err := simulator.GetRunnerFactory(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	w := &Worker{opts: opts, pool: simulator.NewRunnerPool(factory), mux: http.NewServeMux()}
+	w.mux.HandleFunc("GET /health", func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	w.mux.HandleFunc("POST "+ExecutePath, w.execute)
+	return w, nil
+}
+
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	w.mux.ServeHTTP(rw, r)
+}
+
+// Metrics returns the merged metrics of the worker's runners.
+func (w *Worker) Metrics() simulator.ExecutionMetrics {
+	return w.pool.Metrics()
+}
+
+func (w *Worker) execute(rw http.ResponseWriter, r *http.Request) {
+	var req executeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(rw, http.StatusBadRequest, "Invalid request format")
+		return
+	}
+	if req.Backend != "" && req.Backend != w.opts.Backend {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("unknown backend %q (this worker runs %q)", req.Backend, w.opts.Backend))
+		return
+	}
+	if req.Shots <= 0 {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("shots must be positive, got %d", req.Shots))
+		return
+	}
+	if w.opts.MaxShots > 0 && req.Shots > w.opts.MaxShots {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("Too many shots (at most %d allowed)", w.opts.MaxShots))
+		return
+	}
+	if w.opts.MaxQubits > 0 && req.Circuit.Qubits > w.opts.MaxQubits {
+		writeError(rw, http.StatusBadRequest, fmt.Sprintf("circuit has %d qubits, which exceeds the limit of %d", req.Circuit.Qubits, w.opts.MaxQubits))
+		return
+	}
+	c, err := req.Circuit.Build()
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "Invalid circuit: "+err.Error())
+		return
+	}
+
+	sim := simulator.NewSimulator(simulator.SimulatorOptions{Shots: req.Shots, Workers: w.opts.Workers, Pool: w.pool})
+	hist, err := sim.RunContext(r.Context(), c)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return // the coordinator went away
+		}
+		writeError(rw, http.StatusInternalServerError, "Circuit execution failed: "+err.Error())
+		return
+	}
+	writeJSON(rw, http.StatusOK, executeResponse{Measurements: hist, Backend: w.opts.Backend, Shots: req.Shots})
+}
+
+func writeError(rw http.ResponseWriter, code int, msg string) {
+	writeJSON(rw, code, errorResponse{Error: msg})
+}
+
+func writeJSON(rw http.ResponseWriter, code int, v any) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(code)
+	json.NewEncoder(rw).Encode(v)
+}
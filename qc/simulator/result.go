@@ -0,0 +1,165 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// BitOrder tells how the characters of a bitstring key map to classical bits.
+type BitOrder string
+
+const (
+	// Clbit0First puts classical bit 0 in the first (leftmost) character:
+	// character i of a key is clbit i. Every runner reports keys in this
+	// order, and so do all histograms returned by Simulator.
+	Clbit0First BitOrder = "clbit0-first"
+	// Clbit0Last puts classical bit 0 in the last character, so a key reads
+	// as a binary number (the convention of Qiskit and most textbooks).
+	Clbit0Last BitOrder = "clbit0-last"
+)
+
+// Result is the outcome of running a circuit for a number of shots.
+type Result struct {
+	// Counts maps bitstrings, in Order, to the number of shots that read them.
+	Counts map[string]int
+	Shots  int
+	Clbits int
+	Order  BitOrder
+	// Backend names the runner; Seed is its "seed" configuration, if any.
+	Backend string
+	Seed    int64
+	// Elapsed is the wall time of the run.
+	Elapsed time.Duration
+}
+
+// NewResult wraps a histogram with keys in Clbit0First order.
+func NewResult(counts map[string]int, clbits int) *Result {
+	shots := 0
+	for _, n := range counts {
+		shots += n
+	}
+	return &Result{Counts: counts, Shots: shots, Clbits: clbits, Order: Clbit0First}
+}
+
+// bit reports whether clbit i of key is set.
+func (r *Result) bit(key string, i int) bool {
+	if r.Order == Clbit0Last {
+		i = len(key) - 1 - i
+	}
+	return i >= 0 && i < len(key) && key[i] == '1'
+}
+
+// WithOrder returns a copy of the result with its keys in the given order.
+func (r *Result) WithOrder(order BitOrder) *Result {
+	out := *r
+	out.Order = order
+	out.Counts = make(map[string]int, len(r.Counts))
+	for k, n := range r.Counts {
+		if order != r.Order {
+			k = reverse(k)
+		}
+		out.Counts[k] += n
+	}
+	return &out
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// IntCounts keys the counts by integer value, where clbit i has weight 2^i
+// whatever the order of the string keys.
+func (r *Result) IntCounts() map[int]int {
+	out := make(map[int]int, len(r.Counts))
+	for k, n := range r.Counts {
+		v := 0
+		for i := range len(k) {
+			if r.bit(k, i) {
+				v |= 1 << i
+			}
+		}
+		out[v] += n
+	}
+	return out
+}
+
+// Probabilities returns the relative frequency of every key.
+func (r *Result) Probabilities() map[string]float64 {
+	out := make(map[string]float64, len(r.Counts))
+	if r.Shots == 0 {
+		return out
+	}
+	for k, n := range r.Counts {
+		out[k] = float64(n) / float64(r.Shots)
+	}
+	return out
+}
+
+// Marginals returns, for every classical bit, the fraction of shots that
+// read 1.
+func (r *Result) Marginals() []float64 {
+	out := make([]float64, r.Clbits)
+	if r.Shots == 0 {
+		return out
+	}
+	for k, n := range r.Counts {
+		for i := range out {
+			if r.bit(k, i) {
+				out[i] += float64(n)
+			}
+		}
+	}
+	for i := range out {
+		out[i] /= float64(r.Shots)
+	}
+	return out
+}
+
+// Marginal counts the outcomes of the given classical bits only; character
+// j of a key is clbits[j].
+func (r *Result) Marginal(clbits ...int) (map[string]int, error) {
+	for _, c := range clbits {
+		if c < 0 || c >= r.Clbits {
+			return nil, fmt.Errorf("clbit %d out of range [0,%d)", c, r.Clbits)
+		}
+	}
+	out := make(map[string]int)
+	key := make([]byte, len(clbits))
+	for k, n := range r.Counts {
+		for j, c := range clbits {
+			key[j] = '0'
+			if r.bit(k, c) {
+				key[j] = '1'
+			}
+		}
+		out[string(key)] += n
+	}
+	return out, nil
+}
+
+// RunResult runs the circuit like Run and describes the outcome as a Result.
+func (s *Simulator) RunResult(c circuit.Circuit) (*Result, error) {
+	start := time.Now()
+	hist, err := s.Run(c)
+	res := NewResult(hist, c.Clbits())
+	res.Elapsed = time.Since(start)
+	res.Backend = s.backend
+	runner := s.describer()
+	if res.Backend == "" {
+		if info := GetBackendInfo(runner); info != nil {
+			res.Backend = info.Name
+		}
+	}
+	if cr, ok := runner.(ConfigurableRunner); ok {
+		if seed, ok := cr.GetConfiguration()["seed"].(int64); ok {
+			res.Seed = seed
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,32 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	assert := assert.New(t)
+
+	// clbit 0 is always 1, clbit 1 half the time, clbit 2 never
+	r := NewResult(map[string]int{"100": 30, "110": 10}, 3)
+	assert.Equal(40, r.Shots)
+	assert.Equal(Clbit0First, r.Order)
+	assert.Equal(map[int]int{1: 30, 3: 10}, r.IntCounts())
+	assert.Equal(map[string]float64{"100": 0.75, "110": 0.25}, r.Probabilities())
+	assert.Equal([]float64{1, 0.25, 0}, r.Marginals())
+
+	m, err := r.Marginal(1, 0)
+	require.NoError(t, err)
+	assert.Equal(map[string]int{"01": 30, "11": 10}, m)
+	_, err = r.Marginal(3)
+	assert.Error(err)
+
+	last := r.WithOrder(Clbit0Last)
+	assert.Equal(map[string]int{"001": 30, "011": 10}, last.Counts)
+	assert.Equal(r.IntCounts(), last.IntCounts(), "integer keys do not depend on the order")
+	assert.Equal(r.Marginals(), last.Marginals())
+	assert.Equal(r.Counts, last.WithOrder(Clbit0First).Counts)
+}
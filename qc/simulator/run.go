@@ -0,0 +1,170 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// PartialRunError reports a run that stopped before all of its shots
+// succeeded, because the context was done or a shot failed. The histogram
+// returned alongside it holds the Completed shots.
+type PartialRunError struct {
+	Completed int   // successful shots, all in the histogram
+	Failed    int   // shots that returned an error
+	Total     int   // shots requested
+	Err       error // ctx.Err() or the first shot error
+}
+
+func (e *PartialRunError) Error() string {
+	return fmt.Sprintf("run stopped after %d of %d shots (%d failed): %v", e.Completed, e.Total, e.Failed, e.Err)
+}
+
+func (e *PartialRunError) Unwrap() error { return e.Err }
+
+// RunContext runs the circuit like Run, honouring ctx: when ctx is done the
+// workers stop promptly and the shots completed so far are returned with a
+// *PartialRunError. A failed shot stops all workers the same way unless
+// SimulatorOptions.ContinueOnError is set. Runners implementing
+// ContextualRunner get ctx for every shot.
+func (s *Simulator) RunContext(ctx context.Context, c circuit.Circuit) (map[string]int, error) {
+	return s.runParallelStatic(ctx, c)
+}
+
+// acquire returns a runner for one worker: its own one from the pool, or
+// else the shared runner.
+func (s *Simulator) acquire() (OneShotRunner, error) {
+	if s.pool == nil {
+		return s.runner, nil
+	}
+	return s.pool.Get()
+}
+
+// release hands a runner from acquire back to the pool.
+func (s *Simulator) release(runner OneShotRunner) {
+	if s.pool != nil && runner != nil {
+		s.pool.Put(runner)
+	}
+}
+
+// run is the state shared by the workers of one run.
+type run struct {
+	sim    *Simulator
+	ctx    context.Context
+	cancel context.CancelFunc
+	total  int
+
+	mu       sync.Mutex
+	hist     map[string]int
+	progress *progressReporter
+	failed   int
+	firstErr error
+}
+
+func (s *Simulator) newRun(ctx context.Context, total int) *run {
+	ctx, cancel := context.WithCancel(ctx)
+	return &run{
+		sim:      s,
+		ctx:      ctx,
+		cancel:   cancel,
+		total:    total,
+		hist:     make(map[string]int),
+		progress: newProgressReporter(s.progress, s.progressEvery, total),
+	}
+}
+
+// acquire returns the runner a worker uses for the whole run, failing the
+// run when there is none.
+func (r *run) acquire() OneShotRunner {
+	runner, err := r.sim.acquire()
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	return runner
+}
+
+// release hands a runner from acquire back.
+func (r *run) release(runner OneShotRunner) {
+	r.sim.release(runner)
+}
+
+// fail stops the run with an error that is not a shot failure.
+func (r *run) fail(err error) {
+	r.mu.Lock()
+	if r.firstErr == nil {
+		r.firstErr = err
+	}
+	r.mu.Unlock()
+	r.cancel()
+}
+
+// shot runs the circuit once on runner and records the outcome. It returns
+// false when the worker should stop: the run was cancelled or, unless errors
+// are tolerated, this shot failed. wrap decorates shot errors.
+func (r *run) shot(runner OneShotRunner, c circuit.Circuit, wrap func(error) error) bool {
+	if runner == nil || r.ctx.Err() != nil {
+		return false
+	}
+	// Contextual runners always get the run's context, so a shot in flight
+	// also stops when another one fails.
+	var key string
+	var err error
+	if cr, ok := runner.(ContextualRunner); ok {
+		key, err = cr.RunOnceWithContext(r.ctx, c)
+	} else {
+		key, err = runner.RunOnce(c)
+	}
+	if err != nil {
+		if r.ctx.Err() != nil {
+			return false // interrupted, not failed
+		}
+		r.mu.Lock()
+		r.failed++
+		if r.firstErr == nil {
+			r.firstErr = wrap(err)
+		}
+		r.mu.Unlock()
+		if !r.sim.continueOnError {
+			r.cancel()
+			return false
+		}
+		return true
+	}
+	r.mu.Lock()
+	r.hist[key]++
+	r.progress.shot(r.hist)
+	r.mu.Unlock()
+	return true
+}
+
+// finish releases the run and returns its histogram and error: nil when
+// every shot succeeded and otherwise a *PartialRunError.
+func (r *run) finish(parent context.Context) (map[string]int, error) {
+	r.cancel()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	completed := 0
+	for _, n := range r.hist {
+		completed += n
+	}
+	if completed == r.total {
+		return r.hist, nil
+	}
+	cause := r.firstErr
+	if cause == nil {
+		cause = parent.Err()
+	}
+	return r.hist, &PartialRunError{Completed: completed, Failed: r.failed, Total: r.total, Err: cause}
+}
+
+// legacyError keeps the error contract of the context-less Run methods,
+// which return the first shot error itself.
+func legacyError(err error) error {
+	if pe, ok := err.(*PartialRunError); ok {
+		return pe.Err
+	}
+	return err
+}
@@ -1,15 +1,23 @@
 package simulator
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/kegliz/qplay/qc/circuit"
 )
 
 // RunSerial executes the circuit serially (one shot after another) and returns
-// a histogram mapping classical bit-strings (little-endian) to counts.
+// a histogram mapping classical bit-strings in Clbit0First order to counts;
+// see Result.WithOrder for the other order.
 // This method provides a simpler, non-concurrent alternative to Run.
 func (s *Simulator) RunSerial(c circuit.Circuit) (map[string]int, error) {
+	hist, err := s.RunSerialContext(context.Background(), c)
+	return hist, legacyError(err)
+}
+
+// RunSerialContext is RunSerial honouring ctx like RunContext.
+func (s *Simulator) RunSerialContext(ctx context.Context, c circuit.Circuit) (map[string]int, error) {
 
 	s.log.Info().
 		Int("shots", s.Shots).
@@ -18,18 +26,21 @@ func (s *Simulator) RunSerial(c circuit.Circuit) (map[string]int, error) {
 		Int("depth", c.Depth()).
 		Msg("itsu: Starting RunSerial")
 
-	hist := make(map[string]int)
-
+	r := s.newRun(ctx, s.Shots)
+	runner := r.acquire()
 	for i := range s.Shots {
-		key, err := s.runner.RunOnce(c) // Run the circuit once
-		if err != nil {
-			err = fmt.Errorf("shot %d failed: %w", i+1, err)
-			s.log.Error().Err(err).Int("shot", i+1).Msg("itsu: Serial shot failed")
-			return hist, err
+		shot := i + 1
+		if !r.shot(runner, c, func(err error) error { return fmt.Errorf("shot %d failed: %w", shot, err) }) {
+			break
 		}
-		hist[key]++
 	}
+	r.release(runner)
 
+	hist, err := r.finish(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("itsu: RunSerial stopped")
+		return hist, err
+	}
 	s.log.Info().Int("shots", s.Shots).Msg("itsu: RunSerial finished successfully")
 	return hist, nil
 }
@@ -14,6 +14,23 @@ type SimulatorOptions struct {
 	Shots   int
 	Workers int // number of concurrent workers (0 => NumCPU)
 	Runner  OneShotRunner
+	// RunnerFactory, when set, gives every worker a runner instance of its
+	// own instead of sharing Runner; the instances are kept in a pool and
+	// reused by later runs.
+	RunnerFactory RunnerFactory
+	// Pool shares runner instances between simulators; it takes precedence
+	// over RunnerFactory.
+	Pool *RunnerPool
+	// Backend names the runner in results (default: its BackendInfo name).
+	Backend string
+	// Progress, when set, receives histogram snapshots while shots run.
+	Progress ProgressFunc
+	// ProgressEvery is the number of shots between snapshots (0 => 1% of
+	// the shots); the final shot always produces one.
+	ProgressEvery int
+	// ContinueOnError keeps running the remaining shots after one fails
+	// instead of stopping all workers; the run still reports the failure.
+	ContinueOnError bool
 }
 
 // Simulator executes an immutable circuit for a given number of shots.
@@ -25,6 +42,12 @@ type Simulator struct {
 	Shots   int
 	Workers int // number of concurrent workers (0 => NumCPU)
 	runner  OneShotRunner
+	pool    *RunnerPool
+	backend string
+
+	progress        ProgressFunc
+	progressEvery   int
+	continueOnError bool
 
 	log logger.Logger
 }
@@ -44,7 +67,14 @@ func NewSimulator(options SimulatorOptions) *Simulator {
 		workers = shots
 	}
 
-	return &Simulator{Shots: shots, Workers: workers, runner: options.Runner,
+	pool := options.Pool
+	if pool == nil && options.RunnerFactory != nil {
+		pool = NewRunnerPool(options.RunnerFactory)
+	}
+
+	return &Simulator{Shots: shots, Workers: workers, runner: options.Runner, pool: pool, backend: options.Backend,
+		progress: options.Progress, progressEvery: options.ProgressEvery,
+		continueOnError: options.ContinueOnError,
 		log: *logger.NewLogger(logger.LoggerOptions{
 			Debug: false,
 		})}
@@ -65,19 +95,59 @@ type OneShotRunner interface {
 	RunOnce(circuit.Circuit) (string, error)
 }
 
-// Run defaults to RunParallelStatic.
+// Run defaults to RunParallelStatic. Like every Run method it returns a
+// histogram of bitstrings in Clbit0First order; see RunResult for a typed
+// result and RunContext for cancellation.
 func (s *Simulator) Run(c circuit.Circuit) (map[string]int, error) {
 	return s.RunParallelStatic(c)
 }
 
-// NewSimulatorWithRunner creates a simulator using a named runner from the plugin registry.
+// Metrics returns the execution metrics of the simulator's runners, merged
+// over every pooled instance. It is zero when the runners collect none.
+func (s *Simulator) Metrics() ExecutionMetrics {
+	if s.pool != nil {
+		return s.pool.Metrics()
+	}
+	if mc, ok := s.runner.(MetricsCollector); ok {
+		return mc.GetMetrics()
+	}
+	return ExecutionMetrics{}
+}
+
+// describer returns a runner to read backend details from: the shared
+// runner, or else a pooled one. It returns nil when none is available.
+func (s *Simulator) describer() OneShotRunner {
+	if s.runner != nil || s.pool == nil {
+		return s.runner
+	}
+	r, err := s.pool.Get()
+	if err != nil {
+		return nil
+	}
+	s.pool.Put(r)
+	return r
+}
+
+// NewSimulatorWithRunner creates a simulator using a named runner from the
+// plugin registry. Every worker gets a runner instance of its own.
 func NewSimulatorWithRunner(runnerName string, options SimulatorOptions) (*Simulator, error) {
-	runner, err := CreateRunner(runnerName)
+	factory, err := GetRunnerFactory(runnerName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create runner %q: %w", runnerName, err)
 	}
+	pool := NewRunnerPool(factory)
+	runner, err := pool.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner %q: %w", runnerName, err)
+	}
+	pool.Put(runner)
 
-	options.Runner = runner
+	options.Runner = nil
+	options.RunnerFactory = nil
+	options.Pool = pool
+	if options.Backend == "" {
+		options.Backend = runnerName
+	}
 	return NewSimulator(options), nil
 }
 
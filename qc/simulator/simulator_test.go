@@ -1,11 +1,13 @@
 package simulator
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/kegliz/qplay/qc/builder"
 	"github.com/kegliz/qplay/qc/circuit"
@@ -215,7 +217,7 @@ func TestSimulator_RunParallelChan(t *testing.T) {
 			}
 			return "0", nil
 		})
-		sim := NewSimulator(SimulatorOptions{Shots: shots, Workers: numWorkers, Runner: mockRunner})
+		sim := NewSimulator(SimulatorOptions{Shots: shots, Workers: numWorkers, Runner: mockRunner, ContinueOnError: true})
 		// sim.SetVerbose(true) // Enable logging to see worker errors if any
 
 		hist, err := sim.RunParallelChan(testCirc)
@@ -250,4 +252,247 @@ func TestSimulator_RunParallelChan(t *testing.T) {
 
 		t.Logf("RunParallelChan with error completed %d calls out of %d shots. Hist: %v, Err: %v", mockRunner.CallCount(), shots, hist, err)
 	})
+
+	t.Run("StopOnError", func(t *testing.T) {
+		mockRunner := newMockOneShotRunner(func(c circuit.Circuit, callNum int) (string, error) {
+			if callNum == 3 {
+				return "", fmt.Errorf("mock runonce error")
+			}
+			return "0", nil
+		})
+		sim := NewSimulator(SimulatorOptions{Shots: 1000, Workers: numWorkers, Runner: mockRunner})
+
+		hist, err := sim.RunParallelChan(testCirc)
+		require.ErrorContains(t, err, "mock runonce error")
+		assert.Less(t, mockRunner.CallCount(), 1000, "the first error stops all workers")
+		assert.Equal(t, mockRunner.CallCount()-1, hist["0"])
+	})
+}
+
+// contextMockRunner counts the shots run through RunOnceWithContext.
+type contextMockRunner struct {
+	*mockOneShotRunner
+	withContext atomic.Int32
+}
+
+func (m *contextMockRunner) RunOnceWithContext(ctx context.Context, c circuit.Circuit) (string, error) {
+	m.withContext.Add(1)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.RunOnce(c)
+}
+
+func TestSimulator_RunContext(t *testing.T) {
+	testCirc := newTestCircuit(t)
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runner := &contextMockRunner{mockOneShotRunner: newMockOneShotRunner(func(c circuit.Circuit, callNum int) (string, error) {
+			if callNum == 50 {
+				cancel()
+			}
+			return "0", nil
+		})}
+		sim := NewSimulator(SimulatorOptions{Shots: 10000, Workers: 4, Runner: runner})
+
+		hist, err := sim.RunContext(ctx, testCirc)
+		var pe *PartialRunError
+		require.ErrorAs(t, err, &pe)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 10000, pe.Total)
+		assert.Zero(t, pe.Failed)
+		assert.Equal(t, pe.Completed, hist["0"], "partial histogram")
+		assert.GreaterOrEqual(t, pe.Completed, 49)
+		assert.Less(t, runner.CallCount(), 1000, "workers stop promptly")
+		assert.Equal(t, int32(runner.CallCount()), runner.withContext.Load(), "contextual runners get the context")
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		runner := newMockOneShotRunner(func(c circuit.Circuit, callNum int) (string, error) {
+			time.Sleep(time.Millisecond)
+			return "1", nil
+		})
+		sim := NewSimulator(SimulatorOptions{Shots: 100000, Workers: 2, Runner: runner})
+
+		hist, err := sim.RunContext(ctx, testCirc)
+		var pe *PartialRunError
+		require.ErrorAs(t, err, &pe)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Positive(t, pe.Completed)
+		assert.Equal(t, pe.Completed, hist["1"])
+	})
+
+	t.Run("ContinueOnError", func(t *testing.T) {
+		runner := newMockOneShotRunner(func(c circuit.Circuit, callNum int) (string, error) {
+			if callNum%10 == 0 {
+				return "", fmt.Errorf("flaky")
+			}
+			return "0", nil
+		})
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 4, Runner: runner, ContinueOnError: true})
+
+		hist, err := sim.RunContext(context.Background(), testCirc)
+		var pe *PartialRunError
+		require.ErrorAs(t, err, &pe)
+		assert.Equal(t, PartialRunError{Completed: 90, Failed: 10, Total: 100, Err: pe.Err}, *pe)
+		assert.EqualError(t, pe.Err, "flaky")
+		assert.Equal(t, 90, hist["0"])
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		sim := NewSimulator(SimulatorOptions{Shots: 100, Workers: 4, Runner: newMockOneShotRunner(nil)})
+		hist, err := sim.RunContext(context.Background(), testCirc)
+		require.NoError(t, err)
+		assert.Equal(t, 100, hist["0"])
+	})
+}
+
+func TestSimulator_Progress(t *testing.T) {
+	testCirc := newTestCircuit(t)
+	shots := 40
+
+	runs := map[string]func(*Simulator, circuit.Circuit) (map[string]int, error){
+		"Serial":         (*Simulator).RunSerial,
+		"ParallelStatic": (*Simulator).RunParallelStatic,
+		"ParallelChan":   (*Simulator).RunParallelChan,
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			var snapshots []Progress
+			sim := NewSimulator(SimulatorOptions{
+				Shots:         shots,
+				Workers:       4,
+				Runner:        newMockOneShotRunner(nil),
+				Progress:      func(p Progress) { snapshots = append(snapshots, p) },
+				ProgressEvery: 15,
+			})
+
+			hist, err := run(sim, testCirc)
+			require.NoError(t, err)
+
+			// Every 15th shot plus the last one.
+			require.Len(t, snapshots, 3)
+			assert.Equal(t, []int{15, 30, 40}, []int{snapshots[0].Completed, snapshots[1].Completed, snapshots[2].Completed})
+			assert.Equal(t, map[string]int{"0": 15}, snapshots[0].Histogram)
+			assert.False(t, snapshots[1].Done())
+			assert.True(t, snapshots[2].Done())
+			assert.Equal(t, hist, snapshots[2].Histogram)
+			assert.Equal(t, shots, snapshots[2].Total)
+
+			// Snapshots are copies.
+			snapshots[2].Histogram["0"] = -1
+			assert.Equal(t, shots, hist["0"])
+		})
+	}
+
+	t.Run("DefaultInterval", func(t *testing.T) {
+		calls := 0
+		sim := NewSimulator(SimulatorOptions{Shots: 1000, Workers: 1, Runner: newMockOneShotRunner(nil), Progress: func(Progress) { calls++ }})
+		_, err := sim.RunSerial(testCirc)
+		require.NoError(t, err)
+		assert.Equal(t, 100, calls, "one snapshot per percent")
+	})
+}
+
+// countingRunner is deliberately not goroutine-safe: pooled runners are
+// never used by two workers at once.
+type countingRunner struct {
+	runs int
+	last time.Time
+}
+
+func (m *countingRunner) RunOnce(c circuit.Circuit) (string, error) {
+	m.runs++
+	m.last = time.Now()
+	return "0", nil
+}
+
+func (m *countingRunner) GetMetrics() ExecutionMetrics {
+	return ExecutionMetrics{TotalExecutions: int64(m.runs), SuccessfulRuns: int64(m.runs), TotalTime: time.Duration(m.runs) * time.Millisecond, LastRunTime: m.last}
+}
+
+func (m *countingRunner) ResetMetrics() { m.runs = 0 }
+
+func TestSimulator_RunnerFactory(t *testing.T) {
+	testCirc := newTestCircuit(t)
+
+	runs := map[string]func(*Simulator, circuit.Circuit) (map[string]int, error){
+		"Serial":         (*Simulator).RunSerial,
+		"ParallelStatic": (*Simulator).RunParallelStatic,
+		"ParallelChan":   (*Simulator).RunParallelChan,
+	}
+	for name, run := range runs {
+		t.Run(name, func(t *testing.T) {
+			var created atomic.Int32
+			factory := func() OneShotRunner {
+				created.Add(1)
+				return &countingRunner{}
+			}
+			sim := NewSimulator(SimulatorOptions{Shots: 200, Workers: 4, RunnerFactory: factory})
+
+			for range 3 {
+				hist, err := run(sim, testCirc)
+				require.NoError(t, err)
+				assert.Equal(t, 200, hist["0"])
+			}
+			assert.LessOrEqual(t, created.Load(), int32(4), "runners are reused across runs")
+
+			m := sim.Metrics()
+			assert.Equal(t, int64(600), m.TotalExecutions)
+			assert.Equal(t, int64(600), m.SuccessfulRuns)
+			assert.Equal(t, time.Millisecond, m.AverageTime)
+		})
+	}
+
+	t.Run("SharedPool", func(t *testing.T) {
+		pool := NewRunnerPool(func() OneShotRunner { return &countingRunner{} })
+		a := NewSimulator(SimulatorOptions{Shots: 10, Workers: 2, Pool: pool})
+		b := NewSimulator(SimulatorOptions{Shots: 10, Workers: 2, Pool: pool})
+		_, err := a.Run(testCirc)
+		require.NoError(t, err)
+		_, err = b.Run(testCirc)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, pool.Size(), 2, "runners are shared between simulators")
+		assert.Equal(t, int64(20), pool.Metrics().TotalExecutions)
+	})
+
+	t.Run("NilRunner", func(t *testing.T) {
+		sim := NewSimulator(SimulatorOptions{Shots: 10, Workers: 2, RunnerFactory: func() OneShotRunner { return nil }})
+		_, err := sim.Run(testCirc)
+		assert.EqualError(t, err, "runner factory returned nil")
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		var created atomic.Int32
+		require.NoError(t, RegisterRunner("counting-test", func() OneShotRunner {
+			created.Add(1)
+			return &countingRunner{}
+		}))
+		defer GetDefaultRegistry().Unregister("counting-test")
+
+		sim, err := NewSimulatorWithRunner("counting-test", SimulatorOptions{Shots: 100, Workers: 4})
+		require.NoError(t, err)
+		_, err = sim.Run(testCirc)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, created.Load(), int32(4), "at most one runner per worker")
+		assert.Equal(t, int64(100), sim.Metrics().TotalExecutions)
+	})
+}
+
+func TestMergeMetrics(t *testing.T) {
+	t0 := time.Now()
+	m := MergeMetrics(
+		ExecutionMetrics{TotalExecutions: 3, SuccessfulRuns: 2, FailedRuns: 1, TotalTime: 3 * time.Second, LastError: "old", LastRunTime: t0},
+		ExecutionMetrics{TotalExecutions: 1, SuccessfulRuns: 1, TotalTime: time.Second, LastRunTime: t0.Add(time.Second)},
+	)
+	assert.Equal(t, ExecutionMetrics{
+		TotalExecutions: 4, SuccessfulRuns: 3, FailedRuns: 1,
+		TotalTime: 4 * time.Second, AverageTime: time.Second,
+		LastRunTime: t0.Add(time.Second),
+	}, m)
+	assert.Equal(t, ExecutionMetrics{}, MergeMetrics())
 }
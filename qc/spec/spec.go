@@ -0,0 +1,205 @@
+// Package spec is the JSON description of a circuit exchanged with the web
+// API. A spec names its gates like gate.Factory does, places them on
+// arbitrary steps and measures into explicit classical bits; Build checks it
+// and turns it into a circuit.Circuit.
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/dag"
+	"github.com/kegliz/qplay/qc/gate"
+)
+
+type (
+	// Circuit describes a whole circuit.
+	Circuit struct {
+		Qubits int `json:"qubits"`
+		// Clbits is the size of the classical register (0 = one per qubit).
+		Clbits int    `json:"clbits,omitempty"`
+		Gates  []Gate `json:"gates"`
+	}
+
+	// Gate places one gate of a Circuit.
+	Gate struct {
+		// Type is any name accepted by gate.Factory, e.g. "H", "cx", "toffoli".
+		Type   string `json:"type"`
+		Qubits []int  `json:"qubits"`
+		// Step orders the gates: lower steps are applied first, gates of the
+		// same step in the order they are listed. Steps need not be contiguous.
+		Step int `json:"step"`
+		// Clbits are the measurement targets of a MEASURE gate, one per
+		// qubit; empty measures every qubit into the bit of the same index.
+		Clbits []int `json:"clbits,omitempty"`
+		// Params are the angles of parametric gates.
+		Params []float64 `json:"params,omitempty"`
+	}
+
+	// GateError reports a problem with one gate of a spec.
+	GateError struct {
+		Index int // position in Circuit.Gates
+		Err   error
+	}
+)
+
+func (e *GateError) Error() string { return fmt.Sprintf("gates[%d]: %v", e.Index, e.Err) }
+func (e *GateError) Unwrap() error { return e.Err }
+
+// clbits returns the effective size of the classical register.
+func (s *Circuit) clbits() int {
+	if s.Clbits == 0 {
+		return s.Qubits
+	}
+	return s.Clbits
+}
+
+// Validate checks the register sizes and every gate without building the
+// circuit. Gate problems are returned as *GateError.
+func (s *Circuit) Validate() error {
+	_, err := s.resolve()
+	return err
+}
+
+// Build converts the spec into a circuit. When no gate measures, every qubit
+// is measured into the classical bit of the same index, which requires at
+// least as many classical bits as qubits.
+func (s *Circuit) Build() (circuit.Circuit, error) {
+	gates, err := s.resolve()
+	if err != nil {
+		return nil, err
+	}
+	d := dag.New(s.Qubits, s.clbits())
+	measured := false
+	for _, rg := range gates {
+		g := s.Gates[rg.index]
+		if rg.gate.Name() != "MEASURE" {
+			err = d.AddGate(rg.gate, g.Qubits)
+		} else {
+			measured = true
+			for k, q := range g.Qubits {
+				if err = d.AddMeasure(q, rg.clbits[k]); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return nil, &GateError{Index: rg.index, Err: err}
+		}
+	}
+	if !measured {
+		for q := 0; q < s.Qubits; q++ {
+			if err := d.AddMeasure(q, q); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return circuit.FromDAG(d), nil
+}
+
+// FromCircuit describes a circuit as a spec, placing every operation on its
+// layout column. Building the spec gives back an equivalent circuit, except
+// that a circuit without measurements gains the implicit ones of Build; the
+// remote runner rejects such circuits for that reason.
+func FromCircuit(c circuit.Circuit) Circuit {
+	s := Circuit{Qubits: c.Qubits(), Clbits: c.Clbits(), Gates: []Gate{}}
+	for _, op := range c.Operations() {
+		g := Gate{Type: op.G.Name(), Qubits: slices.Clone(op.Qubits), Step: op.TimeStep}
+		if op.G.Name() == "MEASURE" && op.Cbit >= 0 {
+			g.Clbits = []int{op.Cbit}
+		}
+		s.Gates = append(s.Gates, g)
+	}
+	return s
+}
+
+// resolvedGate is a validated gate of the spec.
+type resolvedGate struct {
+	index  int
+	gate   gate.Gate
+	clbits []int
+}
+
+// resolve validates the spec and returns its gates in application order.
+func (s *Circuit) resolve() ([]resolvedGate, error) {
+	if s.Qubits <= 0 {
+		return nil, fmt.Errorf("qubits: must be positive, got %d", s.Qubits)
+	}
+	if s.Clbits < 0 {
+		return nil, fmt.Errorf("clbits: must not be negative, got %d", s.Clbits)
+	}
+	gates := make([]resolvedGate, 0, len(s.Gates))
+	measured := false
+	for i := range s.Gates {
+		rg, err := s.resolveGate(i)
+		if err != nil {
+			return nil, &GateError{Index: i, Err: err}
+		}
+		measured = measured || rg.clbits != nil
+		gates = append(gates, rg)
+	}
+	if !measured && s.clbits() < s.Qubits {
+		return nil, fmt.Errorf("clbits: %d classical bits cannot hold the implicit measurement of %d qubits; add MEASURE gates", s.clbits(), s.Qubits)
+	}
+	sort.SliceStable(gates, func(a, b int) bool { return s.Gates[gates[a].index].Step < s.Gates[gates[b].index].Step })
+	return gates, nil
+}
+
+func (s *Circuit) resolveGate(i int) (resolvedGate, error) {
+	g := s.Gates[i]
+	gt, err := gate.Factory(g.Type)
+	if err != nil {
+		var unknown gate.ErrUnknownGate
+		if errors.As(err, &unknown) {
+			return resolvedGate{}, fmt.Errorf("unknown gate type %q", g.Type)
+		}
+		return resolvedGate{}, err
+	}
+	if g.Step < 0 {
+		return resolvedGate{}, fmt.Errorf("step must not be negative, got %d", g.Step)
+	}
+	if len(g.Params) != 0 {
+		return resolvedGate{}, fmt.Errorf("%s takes no parameters, got %d", gt.Name(), len(g.Params))
+	}
+	measure := gt.Name() == "MEASURE"
+	switch {
+	case measure && len(g.Qubits) == 0:
+		return resolvedGate{}, fmt.Errorf("MEASURE needs at least 1 qubit")
+	case !measure && len(g.Qubits) != gt.QubitSpan():
+		return resolvedGate{}, fmt.Errorf("%s needs %d qubit(s), got %d", gt.Name(), gt.QubitSpan(), len(g.Qubits))
+	case !measure && len(g.Clbits) != 0:
+		return resolvedGate{}, fmt.Errorf("only MEASURE takes clbits")
+	}
+	seen := make(map[int]bool, len(g.Qubits))
+	for _, q := range g.Qubits {
+		if q < 0 || q >= s.Qubits {
+			return resolvedGate{}, fmt.Errorf("qubit %d out of range (circuit has %d)", q, s.Qubits)
+		}
+		if seen[q] {
+			return resolvedGate{}, fmt.Errorf("qubit %d used twice", q)
+		}
+		seen[q] = true
+	}
+	rg := resolvedGate{index: i, gate: gt}
+	if !measure {
+		return rg, nil
+	}
+	rg.clbits = g.Clbits
+	if len(rg.clbits) == 0 {
+		rg.clbits = g.Qubits
+	} else if len(rg.clbits) != len(g.Qubits) {
+		return resolvedGate{}, fmt.Errorf("MEASURE has %d qubit(s) but %d clbit(s)", len(g.Qubits), len(g.Clbits))
+	}
+	for _, c := range rg.clbits {
+		if c < 0 || c >= s.clbits() {
+			return resolvedGate{}, fmt.Errorf("clbit %d out of range (circuit has %d)", c, s.clbits())
+		}
+	}
+	return rg, nil
+}
@@ -0,0 +1,121 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	var s Circuit
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"qubits": 3,
+		"clbits": 2,
+		"gates": [
+			{"type": "measure", "qubits": [2, 0], "clbits": [0, 1], "step": 40},
+			{"type": "ccx", "qubits": [0, 1, 2], "step": 25},
+			{"type": "X", "qubits": [0], "step": 12},
+			{"type": "X", "qubits": [1], "step": 12},
+			{"type": "cswap", "qubits": [2, 0, 1], "step": 30}
+		]
+	}`), &s))
+
+	c, err := s.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Qubits())
+	assert.Equal(t, 2, c.Clbits())
+
+	var names []string
+	cbits := map[int]int{}
+	for _, op := range c.Operations() {
+		names = append(names, op.G.Name())
+		if op.Cbit >= 0 {
+			cbits[op.Qubits[0]] = op.Cbit
+		}
+	}
+	assert.Equal(t, []string{"X", "X", "TOFFOLI", "FREDKIN", "MEASURE", "MEASURE"}, names, "gates beyond step 10 are kept, in step order")
+	assert.Equal(t, map[int]int{2: 0, 0: 1}, cbits, "measurements go to the requested clbits")
+}
+
+func TestBuild_ImplicitMeasure(t *testing.T) {
+	s := Circuit{Qubits: 2, Gates: []Gate{{Type: "H", Qubits: []int{0}}}}
+	c, err := s.Build()
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Clbits(), "clbits default to one per qubit")
+	measured := map[int]int{}
+	for _, op := range c.Operations() {
+		if op.G.Name() == "MEASURE" {
+			measured[op.Qubits[0]] = op.Cbit
+		}
+	}
+	assert.Equal(t, map[int]int{0: 0, 1: 1}, measured)
+
+	s.Clbits = 1
+	err = s.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "clbits")
+}
+
+func TestValidate_GateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		gate Gate
+		msg  string
+	}{
+		{"UnknownType", Gate{Type: "RZZ", Qubits: []int{0}}, `unknown gate type "RZZ"`},
+		{"Arity", Gate{Type: "CNOT", Qubits: []int{0}}, "CNOT needs 2 qubit(s), got 1"},
+		{"QubitRange", Gate{Type: "H", Qubits: []int{3}}, "qubit 3 out of range"},
+		{"DuplicateQubit", Gate{Type: "CZ", Qubits: []int{1, 1}}, "qubit 1 used twice"},
+		{"NegativeStep", Gate{Type: "H", Qubits: []int{0}, Step: -1}, "step must not be negative"},
+		{"Params", Gate{Type: "H", Qubits: []int{0}, Params: []float64{0.5}}, "H takes no parameters"},
+		{"ClbitsOnGate", Gate{Type: "X", Qubits: []int{0}, Clbits: []int{0}}, "only MEASURE takes clbits"},
+		{"ClbitRange", Gate{Type: "MEASURE", Qubits: []int{0}, Clbits: []int{5}}, "clbit 5 out of range"},
+		{"ClbitCount", Gate{Type: "MEASURE", Qubits: []int{0, 1}, Clbits: []int{0}}, "2 qubit(s) but 1 clbit(s)"},
+		{"EmptyMeasure", Gate{Type: "MEASURE"}, "MEASURE needs at least 1 qubit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Circuit{Qubits: 3, Gates: []Gate{{Type: "H", Qubits: []int{0}}, tt.gate}}
+			err := s.Validate()
+			var ge *GateError
+			require.True(t, errors.As(err, &ge), "got %v", err)
+			assert.Equal(t, 1, ge.Index)
+			assert.Contains(t, err.Error(), "gates[1]: ")
+			assert.Contains(t, err.Error(), tt.msg)
+
+			_, err = s.Build()
+			assert.ErrorAs(t, err, &ge)
+		})
+	}
+}
+
+func TestValidate_Registers(t *testing.T) {
+	assert.Error(t, (&Circuit{Qubits: 0}).Validate())
+	assert.Error(t, (&Circuit{Qubits: 1, Clbits: -1}).Validate())
+	assert.NoError(t, (&Circuit{Qubits: 1}).Validate())
+}
+
+func TestFromCircuit(t *testing.T) {
+	b := builder.New(builder.Q(3), builder.C(2))
+	b.H(0).CNOT(0, 1).X(2).Toffoli(0, 1, 2).Measure(2, 0).Measure(0, 1)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+
+	s := FromCircuit(c)
+	assert.Equal(t, 2, s.Clbits)
+	assert.Len(t, s.Gates, 6)
+
+	// the spec survives JSON and builds the same circuit
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	var back Circuit
+	require.NoError(t, json.Unmarshal(data, &back))
+	rebuilt, err := back.Build()
+	require.NoError(t, err)
+	assert.Equal(t, circuit.Hash(c), circuit.Hash(rebuilt))
+}
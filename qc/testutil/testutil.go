@@ -11,6 +11,7 @@ import (
 
 	"github.com/kegliz/qplay/qc/builder"
 	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/unitary"
 	"github.com/stretchr/testify/require"
 )
 
@@ -184,6 +185,26 @@ func AssertHistogramDistribution(t *testing.T, hist map[string]int, expected map
 	}
 }
 
+// AssertEquivalent fails the test unless got implements the same operation
+// as want up to global phase, e.g. after optimizing or transpiling want
+func AssertEquivalent(t *testing.T, want, got circuit.Circuit, msgAndArgs ...interface{}) {
+	t.Helper()
+
+	ok, err := unitary.Equivalent(want, got)
+	require.NoError(t, err, msgAndArgs...)
+	require.True(t, ok, msgAndArgs...)
+}
+
+// AssertNotEquivalent fails the test if got implements the same operation
+// as want up to global phase
+func AssertNotEquivalent(t *testing.T, want, got circuit.Circuit, msgAndArgs ...interface{}) {
+	t.Helper()
+
+	ok, err := unitary.Equivalent(want, got)
+	require.NoError(t, err, msgAndArgs...)
+	require.False(t, ok, msgAndArgs...)
+}
+
 // RequireWithinTimeout runs a function with timeout and fails the test if it times out
 func RequireWithinTimeout(t *testing.T, timeout time.Duration, fn func() error, msgAndArgs ...interface{}) {
 	t.Helper()
@@ -0,0 +1,72 @@
+package unitary
+
+import (
+	"math"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+var (
+	h = complex(1/math.Sqrt2, 0)
+
+	// single holds the row-major matrices of the single-qubit gates. They
+	// and evolve are kept apart from the simulator kernels on purpose, as
+	// an independent reference; TestUnitary_MatchesQSim compares the two
+	// gate by gate.
+	single = map[string][4]complex128{
+		"H": {h, h, h, -h},
+		"X": {0, 1, 1, 0},
+		"Y": {0, -1i, 1i, 0},
+		"Z": {1, 0, 0, -1},
+		"S": {1, 0, 0, 1i},
+	}
+
+	// multi lists the gates applied as permutations or phases.
+	multi = map[string]bool{
+		"CNOT": true, "CZ": true, "SWAP": true, "TOFFOLI": true, "FREDKIN": true,
+	}
+)
+
+// evolve applies the gates of a checked circuit to state in place.
+func evolve(c circuit.Circuit, state []complex128) {
+	for _, op := range c.Operations() {
+		q := op.Qubits
+		if m, ok := single[op.G.Name()]; ok {
+			mask := 1 << q[0]
+			for i := range state {
+				if i&mask == 0 {
+					a0, a1 := state[i], state[i|mask]
+					state[i] = m[0]*a0 + m[1]*a1
+					state[i|mask] = m[2]*a0 + m[3]*a1
+				}
+			}
+			continue
+		}
+		switch op.G.Name() {
+		case "CNOT": // control q0, target q1
+			swapWhere(state, 1<<q[0]|1<<q[1], 1<<q[0], 1<<q[1])
+		case "CZ":
+			both := 1<<q[0] | 1<<q[1]
+			for i := range state {
+				if i&both == both {
+					state[i] = -state[i]
+				}
+			}
+		case "SWAP":
+			swapWhere(state, 1<<q[0]|1<<q[1], 1<<q[0], 1<<q[0]|1<<q[1])
+		case "TOFFOLI": // controls q0, q1, target q2
+			swapWhere(state, 1<<q[0]|1<<q[1]|1<<q[2], 1<<q[0]|1<<q[1], 1<<q[2])
+		case "FREDKIN": // control q0, swaps q1 and q2
+			swapWhere(state, 1<<q[0]|1<<q[1]|1<<q[2], 1<<q[0]|1<<q[1], 1<<q[1]|1<<q[2])
+		}
+	}
+}
+
+// swapWhere swaps every amplitude i with i^flip for which i&mask == want.
+func swapWhere(state []complex128, mask, want, flip int) {
+	for i := range state {
+		if i&mask == want {
+			state[i], state[i^flip] = state[i^flip], state[i]
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package unitary computes the matrix of a measurement-free circuit and
+// checks whether two circuits implement the same operation up to global
+// phase, e.g. to verify that an optimized circuit still matches the
+// original. Indices follow the simulators: bit q of a basis-state index is
+// the value of qubit q.
+package unitary
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"slices"
+
+	"github.com/kegliz/qplay/qc/circuit"
+)
+
+// MaxQubits is the largest circuit Unitary accepts. The matrix of n qubits
+// has 4^n entries, so 10 qubits already take 16 MiB.
+const MaxQubits = 10
+
+// ExactQubits is the largest circuit Equivalent compares by its full
+// unitary; larger circuits are compared by running both on random states.
+const ExactQubits = 6
+
+// MaxStateQubits is the largest circuit Equivalent accepts. The random
+// states of n qubits hold 2^n amplitudes each, so 24 qubits take 256 MiB
+// per state.
+const MaxStateQubits = 24
+
+// Tolerance is the largest difference between two matrix or state entries
+// that are still treated as equal.
+const Tolerance = 1e-9
+
+// trials is the number of random states Equivalent tries on large circuits;
+// a single one already separates different unitaries with probability 1.
+const trials = 8
+
+// Matrix is a square complex matrix indexed [row][column], as returned by
+// Unitary. Row and column indices are basis states with bit q holding the
+// value of qubit q.
+type Matrix [][]complex128
+
+// Unitary returns the matrix of c: column j is the state c produces from
+// the basis state |j⟩. The circuit must not measure.
+func Unitary(c circuit.Circuit) (Matrix, error) {
+	n := c.Qubits()
+	if n > MaxQubits {
+		return nil, fmt.Errorf("circuit has %d qubits, the unitary is limited to %d", n, MaxQubits)
+	}
+	if err := check(c); err != nil {
+		return nil, err
+	}
+
+	dim := 1 << n
+	u := make(Matrix, dim)
+	for r := range u {
+		u[r] = make([]complex128, dim)
+	}
+	state := make([]complex128, dim)
+	for col := range dim {
+		clear(state)
+		state[col] = 1
+		evolve(c, state)
+		for r, a := range state {
+			u[r][col] = a
+		}
+	}
+	return u, nil
+}
+
+// Equivalent reports whether a and b act identically on every state up to
+// a global phase. Circuits of at most ExactQubits qubits are compared by
+// their unitaries, larger ones by running both on random states. Circuits
+// on different numbers of qubits are never equivalent, and circuits above
+// MaxStateQubits are rejected.
+func Equivalent(a, b circuit.Circuit) (bool, error) {
+	if err := check(a); err != nil {
+		return false, err
+	}
+	if err := check(b); err != nil {
+		return false, err
+	}
+	n := a.Qubits()
+	if n != b.Qubits() {
+		return false, nil
+	}
+	if n > MaxStateQubits {
+		return false, fmt.Errorf("circuit has %d qubits, equivalence checks are limited to %d", n, MaxStateQubits)
+	}
+
+	if n <= ExactQubits {
+		ua, err := Unitary(a)
+		if err != nil {
+			return false, err
+		}
+		ub, err := Unitary(b)
+		if err != nil {
+			return false, err
+		}
+		return ua.EqualUpToPhase(ub), nil
+	}
+
+	// The seed is fixed so a verdict can be reproduced
+	rng := rand.New(rand.NewSource(1))
+	var phase complex128
+	for range trials {
+		sa := randomState(rng, n)
+		sb := slices.Clone(sa)
+		evolve(a, sa)
+		evolve(b, sb)
+		if !equalUpToPhase(sa, sb, &phase) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// EqualUpToPhase reports whether o is e^{iφ}·m for some φ, entry by entry
+// within Tolerance.
+func (m Matrix) EqualUpToPhase(o Matrix) bool {
+	if len(m) != len(o) {
+		return false
+	}
+	var phase complex128
+	for r := range m {
+		if len(m[r]) != len(o[r]) || !equalUpToPhase(m[r], o[r], &phase) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalUpToPhase reports whether y is phase·x. A zero phase is fixed from
+// the largest entry of x, so successive calls share one global phase.
+func equalUpToPhase(x, y []complex128, phase *complex128) bool {
+	if *phase == 0 {
+		k, largest := 0, 0.0
+		for i, v := range x {
+			if a := cmplx.Abs(v); a > largest {
+				k, largest = i, a
+			}
+		}
+		if largest <= Tolerance {
+			// Nothing to fix the phase with yet; y must vanish too
+			for _, v := range y {
+				if cmplx.Abs(v) > Tolerance {
+					return false
+				}
+			}
+			return true
+		}
+		p := y[k] / x[k]
+		if math.Abs(cmplx.Abs(p)-1) > Tolerance {
+			return false
+		}
+		*phase = p
+	}
+	for i := range x {
+		if cmplx.Abs(y[i]-*phase*x[i]) > Tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// randomState returns a normalized state with Gaussian amplitudes, which
+// is uniformly distributed over the unit sphere.
+func randomState(rng *rand.Rand, n int) []complex128 {
+	state := make([]complex128, 1<<n)
+	var norm float64
+	for i := range state {
+		state[i] = complex(rng.NormFloat64(), rng.NormFloat64())
+		norm += real(state[i])*real(state[i]) + imag(state[i])*imag(state[i])
+	}
+	scale := complex(1/math.Sqrt(norm), 0)
+	for i := range state {
+		state[i] *= scale
+	}
+	return state
+}
+
+// check rejects circuits evolve cannot apply.
+func check(c circuit.Circuit) error {
+	for _, op := range c.Operations() {
+		name := op.G.Name()
+		if name == "MEASURE" {
+			return fmt.Errorf("circuit measures qubit %d at step %d; only measurement-free circuits have a unitary", op.Qubits[0], op.TimeStep)
+		}
+		if _, ok := single[name]; !ok && !multi[name] {
+			return fmt.Errorf("unsupported gate: %s", name)
+		}
+		for _, q := range op.Qubits {
+			if q < 0 || q >= c.Qubits() {
+				return fmt.Errorf("invalid qubit %d for %d-qubit circuit", q, c.Qubits())
+			}
+		}
+	}
+	return nil
+}
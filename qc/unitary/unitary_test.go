@@ -0,0 +1,230 @@
+package unitary_test
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"testing"
+
+	"github.com/kegliz/qplay/qc/builder"
+	"github.com/kegliz/qplay/qc/circuit"
+	"github.com/kegliz/qplay/qc/simulator/qsim"
+	"github.com/kegliz/qplay/qc/testutil"
+	"github.com/kegliz/qplay/qc/unitary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func build(t *testing.T, qubits int, gates func(b builder.Builder)) circuit.Circuit {
+	t.Helper()
+	b := builder.New(builder.Q(qubits), builder.C(qubits))
+	gates(b)
+	c, err := b.BuildCircuit()
+	require.NoError(t, err)
+	return c
+}
+
+func TestUnitary(t *testing.T) {
+	h := complex(1/math.Sqrt2, 0)
+	u, err := unitary.Unitary(build(t, 1, func(b builder.Builder) { b.H(0) }))
+	require.NoError(t, err)
+	assert.True(t, u.EqualUpToPhase(unitary.Matrix{{h, h}, {h, -h}}))
+
+	// Control qubit 0 is the low bit: |01⟩ (index 1) goes to |11⟩ (index 3)
+	u, err = unitary.Unitary(build(t, 2, func(b builder.Builder) { b.CNOT(0, 1) }))
+	require.NoError(t, err)
+	assert.Equal(t, unitary.Matrix{
+		{1, 0, 0, 0},
+		{0, 0, 0, 1},
+		{0, 0, 1, 0},
+		{0, 1, 0, 0},
+	}, u)
+
+	// Fredkin with control 0 swaps qubits 1 and 2: |011⟩ ↔ |101⟩
+	u, err = unitary.Unitary(build(t, 3, func(b builder.Builder) { b.Fredkin(0, 1, 2) }))
+	require.NoError(t, err)
+	assert.Equal(t, complex128(1), u[0b101][0b011])
+	assert.Equal(t, complex128(1), u[0b011][0b101])
+
+	_, err = unitary.Unitary(build(t, 2, func(b builder.Builder) { b.H(0).Measure(0, 0) }))
+	assert.ErrorContains(t, err, "measurement-free")
+
+	_, err = unitary.Unitary(build(t, unitary.MaxQubits+1, func(b builder.Builder) { b.H(0) }))
+	assert.Error(t, err)
+}
+
+func TestUnitary_IsUnitary(t *testing.T) {
+	c := randomCircuit(t, rand.New(rand.NewSource(1)), 4, 40)
+	u, err := unitary.Unitary(c)
+	require.NoError(t, err)
+	for i := range u {
+		for j := range u {
+			var dot complex128
+			for k := range u {
+				dot += complex(real(u[k][i]), -imag(u[k][i])) * u[k][j]
+			}
+			want := complex128(0)
+			if i == j {
+				want = 1
+			}
+			assert.InDelta(t, real(want), real(dot), unitary.Tolerance)
+			assert.InDelta(t, imag(want), imag(dot), unitary.Tolerance)
+		}
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	cases := []struct {
+		name   string
+		qubits int
+		a, b   func(b builder.Builder)
+		want   bool
+	}{
+		{"HZH is X", 1, func(b builder.Builder) { b.H(0).Z(0).H(0) }, func(b builder.Builder) { b.X(0) }, true},
+		{"SS is Z", 1, func(b builder.Builder) { b.S(0).S(0) }, func(b builder.Builder) { b.Z(0) }, true},
+		{"Y is XZ up to phase", 1, func(b builder.Builder) { b.Y(0) }, func(b builder.Builder) { b.Z(0).X(0) }, true},
+		{"X is not Z", 1, func(b builder.Builder) { b.X(0) }, func(b builder.Builder) { b.Z(0) }, false},
+		{"CNOT from CZ", 2, func(b builder.Builder) { b.CNOT(0, 1) }, func(b builder.Builder) { b.H(1).CZ(0, 1).H(1) }, true},
+		{"CNOT direction matters", 2, func(b builder.Builder) { b.CNOT(0, 1) }, func(b builder.Builder) { b.CNOT(1, 0) }, false},
+		{"SWAP from CNOTs", 2, func(b builder.Builder) { b.SWAP(0, 1) }, func(b builder.Builder) { b.CNOT(0, 1).CNOT(1, 0).CNOT(0, 1) }, true},
+		{"relative phase is not global", 2, func(b builder.Builder) { b.H(0) }, func(b builder.Builder) { b.H(0).Z(1) }, false},
+		{"Fredkin from Toffoli", 3, func(b builder.Builder) { b.Fredkin(0, 1, 2) }, func(b builder.Builder) { b.CNOT(2, 1).Toffoli(0, 1, 2).CNOT(2, 1) }, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := unitary.Equivalent(build(t, tc.qubits, tc.a), build(t, tc.qubits, tc.b))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ok)
+		})
+	}
+
+	ok, err := unitary.Equivalent(build(t, 1, func(b builder.Builder) { b.X(0) }), build(t, 2, func(b builder.Builder) { b.X(0) }))
+	require.NoError(t, err)
+	assert.False(t, ok, "circuits on different qubit counts")
+
+	_, err = unitary.Equivalent(build(t, 1, func(b builder.Builder) { b.X(0) }), build(t, 1, func(b builder.Builder) { b.Measure(0, 0) }))
+	assert.Error(t, err)
+}
+
+// TestEquivalent_RandomStates covers circuits above ExactQubits, which are
+// compared on random states instead of full unitaries.
+func TestEquivalent_RandomStates(t *testing.T) {
+	const qubits = unitary.ExactQubits + 4
+	rng := rand.New(rand.NewSource(2))
+	original := randomCircuit(t, rng, qubits, 80)
+
+	// Rewrite every SWAP as three CNOTs and every CNOT as H CZ H
+	rewritten := build(t, qubits, func(b builder.Builder) {
+		for _, op := range original.Operations() {
+			q := op.Qubits
+			switch op.G.Name() {
+			case "SWAP":
+				b.H(q[1]).CZ(q[0], q[1]).H(q[1])
+				b.CNOT(q[1], q[0])
+				b.H(q[1]).CZ(q[0], q[1]).H(q[1])
+			case "CNOT":
+				b.H(q[1]).CZ(q[0], q[1]).H(q[1])
+			default:
+				apply(b, op)
+			}
+		}
+	})
+	testutil.AssertEquivalent(t, original, rewritten)
+
+	// Dropping a single gate is caught
+	ops := original.Operations()
+	broken := build(t, qubits, func(b builder.Builder) {
+		for _, op := range ops[:len(ops)/2] {
+			apply(b, op)
+		}
+		for _, op := range ops[len(ops)/2+1:] {
+			apply(b, op)
+		}
+	})
+	testutil.AssertNotEquivalent(t, original, broken)
+
+	large := build(t, unitary.MaxStateQubits+1, func(b builder.Builder) { b.H(0) })
+	_, err := unitary.Equivalent(large, large)
+	assert.ErrorContains(t, err, "limited to")
+}
+
+// TestUnitary_MatchesQSim checks the gate matrices of this package, kept
+// apart from the simulator's as an independent reference, against qsim gate
+// by gate: column j of the unitary is the state qsim reaches from |j⟩.
+func TestUnitary_MatchesQSim(t *testing.T) {
+	const qubits = 3
+	order := []int{2, 0, 1} // controls and targets out of index order
+	runner := qsim.NewQSimRunner()
+	for _, name := range runner.GetSupportedGates() {
+		if name == "MEASURE" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			gateOnly := build(t, qubits, func(b builder.Builder) { add(b, name, order) })
+			require.Len(t, gateOnly.Operations(), 1, "the test cannot build gate %s", name)
+			u, err := unitary.Unitary(gateOnly)
+			require.NoError(t, err)
+
+			for col := range 1 << qubits {
+				c := build(t, qubits, func(b builder.Builder) {
+					for q := range qubits {
+						if col>>q&1 == 1 {
+							b.X(q)
+						}
+					}
+					add(b, name, order)
+				})
+				state, err := runner.StateVector(c)
+				require.NoError(t, err)
+				for row, a := range state {
+					assert.InDelta(t, 0, cmplx.Abs(a-u[row][col]), unitary.Tolerance,
+						"%s: amplitude of |%03b⟩ from |%03b⟩", name, row, col)
+				}
+			}
+		})
+	}
+}
+
+func apply(b builder.Builder, op circuit.Operation) {
+	add(b, op.G.Name(), op.Qubits)
+}
+
+func add(b builder.Builder, name string, q []int) {
+	switch name {
+	case "H":
+		b.H(q[0])
+	case "X":
+		b.X(q[0])
+	case "Y":
+		b.Y(q[0])
+	case "Z":
+		b.Z(q[0])
+	case "S":
+		b.S(q[0])
+	case "CNOT":
+		b.CNOT(q[0], q[1])
+	case "CZ":
+		b.CZ(q[0], q[1])
+	case "SWAP":
+		b.SWAP(q[0], q[1])
+	case "TOFFOLI":
+		b.Toffoli(q[0], q[1], q[2])
+	case "FREDKIN":
+		b.Fredkin(q[0], q[1], q[2])
+	}
+}
+
+// randomCircuit builds a measurement-free circuit whose gates do not
+// commute trivially: every fourth gate is a Hadamard.
+func randomCircuit(t *testing.T, rng *rand.Rand, qubits, gates int) circuit.Circuit {
+	names := []string{"H", "X", "Y", "Z", "S", "CNOT", "CZ", "SWAP", "TOFFOLI", "FREDKIN"}
+	return build(t, qubits, func(b builder.Builder) {
+		for i := range gates {
+			name := names[rng.Intn(len(names))]
+			if i%4 == 0 {
+				name = "H"
+			}
+			add(b, name, rng.Perm(qubits))
+		}
+	})
+}